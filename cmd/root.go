@@ -20,6 +20,7 @@ var (
 	hostname string
 	database string
 	dir      string
+	config   string
 	port     int
 )
 
@@ -52,8 +53,16 @@ Example:
 			os.Exit(1)
 		}
 		defer db.Close()
+		var cfg *gen.Config
+		if config != "" {
+			cfg, err = gen.LoadConfig(config)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		}
 		// run the generator
-		if err := gen.Generate(db, database, pkg, dir); err != nil {
+		if err := gen.GenerateWithConfig(db, database, pkg, dir, cfg); err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
@@ -75,4 +84,5 @@ func init() {
 	RootCmd.Flags().StringVar(&hostname, "hostname", "localhost", "database hostname")
 	RootCmd.Flags().StringVar(&dir, "dir", "", "output directory to place generated files")
 	RootCmd.Flags().IntVar(&port, "port", 3306, "database port")
+	RootCmd.Flags().StringVar(&config, "config", "", "optional JSON file with per table generator configuration")
 }
@@ -0,0 +1,67 @@
+package gen
+
+import (
+	"encoding/json"
+	"io/ioutil"
+)
+
+// JSONType is the proto message or plain Go type a JSON column is generated as instead of a string. only one of Message and GoType can be set
+type JSONType struct {
+	// Message is the fully qualified proto message name such as google.protobuf.Struct
+	Message string `json:"message,omitempty"`
+	// Import is the proto file which defines the message. it can be omitted for the well known types
+	Import string `json:"import,omitempty"`
+	// GoType is a plain Go type such as settings.Options which is encoded with encoding/json. the column stays a string in the message and is read and written with generated accessors
+	GoType string `json:"go_type,omitempty"`
+	// GoImport is the package which defines the Go type. it can be omitted for a builtin type such as map[string]interface{}
+	GoImport string `json:"go_import,omitempty"`
+}
+
+// well known messages which can be used without specifying the proto import
+var wellKnownJSONImports = map[string]string{
+	"google.protobuf.Struct":    "google/protobuf/struct.proto",
+	"google.protobuf.Value":     "google/protobuf/struct.proto",
+	"google.protobuf.ListValue": "google/protobuf/struct.proto",
+}
+
+// TableConfig is the generator configuration for a specific table
+type TableConfig struct {
+	// JSONTypes maps the name of a JSON column to the message or Go type it should be generated as
+	JSONTypes map[string]*JSONType `json:"json_types,omitempty"`
+	// IDStrategy is how the primary key is filled in when a record is created (auto, uuid, uuidv7, ulid or hash)
+	IDStrategy string `json:"id_strategy,omitempty"`
+	// SoftDelete is the nullable timestamp column which marks a record as deleted. it defaults to deleted_at when the column exists and - turns it off
+	SoftDelete string `json:"soft_delete,omitempty"`
+	// CreatedAt is the timestamp column set when a record is created. it defaults to created_at when the column exists and - turns it off
+	CreatedAt string `json:"created_at,omitempty"`
+	// UpdatedAt is the timestamp column set whenever a record is written. it defaults to updated_at when the column exists and - turns it off
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+// Config is the generator configuration which is usually loaded from a JSON file
+type Config struct {
+	Tables map[string]*TableConfig `json:"tables,omitempty"`
+}
+
+// Table returns the configuration for the named table which will be empty if not configured
+func (c *Config) Table(name string) *TableConfig {
+	if c != nil && c.Tables != nil {
+		if t := c.Tables[name]; t != nil {
+			return t
+		}
+	}
+	return &TableConfig{}
+}
+
+// LoadConfig will load the generator configuration from a JSON file
+func LoadConfig(filename string) (*Config, error) {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	if err := json.Unmarshal(buf, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/jmoiron/sqlx"
@@ -94,16 +95,53 @@ type column struct {
 	maxlength  int64
 	nullable   bool
 	defvalue   string
+	jsontype   *JSONType
+	// autoincrement is true when the column is an auto_increment column
+	autoincrement bool
 }
 
 func (c *column) IsChecksum() bool {
 	return c.name == "checksum"
 }
 
+// IsVersion returns true if the column is an integer version column used for optimistic concurrency
+func (c *column) IsVersion() bool {
+	return c.name == "version" && (c.prototype == "int32" || c.prototype == "int64")
+}
+
 func (c *column) IsJSON() bool {
 	return c.datatype == "json"
 }
 
+// IsTypedJSON returns true if the JSON column has been mapped to a proto message
+func (c *column) IsTypedJSON() bool {
+	return c.jsontype != nil && c.jsontype.Message != ""
+}
+
+// IsGoJSON returns true if the JSON column has been mapped to a plain Go type
+func (c *column) IsGoJSON() bool {
+	return c.jsontype != nil && c.jsontype.GoType != ""
+}
+
+// GenerateJSONEncode returns the code to encode a typed JSON column into a local variable before it's written
+func (c *column) GenerateJSONEncode(prefix string, returnvalue string) string {
+	var buf bytes.Buffer
+	buf.WriteString("\t_" + c.name + ", err := orm.ToSQLJSON(\"" + c.name + "\", " + prefix + CamelCase(c.name) + ")\n")
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\treturn " + returnvalue + ", err\n")
+	buf.WriteString("\t}\n")
+	return buf.String()
+}
+
+// GenerateJSONDecode returns the code to decode a typed JSON column from the scanned variable into the record
+func (c *column) GenerateJSONDecode(prefix string, indent string, returnvalue string) string {
+	var buf bytes.Buffer
+	buf.WriteString(indent + "if err := orm.FromSQLJSON(\"" + c.name + "\", _" + c.name + ", &" + prefix + CamelCase(c.name) + "); err != nil {\n")
+	buf.WriteString(indent + "\treturn " + returnvalue + ", err\n")
+	buf.WriteString(indent + "}\n")
+	return buf.String()
+}
+
 func (c *column) GenerateProtobuf() string {
 	var buf bytes.Buffer
 	if c.enums != nil {
@@ -134,10 +172,143 @@ func (c *column) GenerateVariableType() string {
 		{
 			return "float32"
 		}
+	case "bytes":
+		{
+			return "[]byte"
+		}
 	}
 	return c.prototype
 }
 
+// GenerateColumnType returns the typed orm column which builds the conditions for the column. an enum column uses the type generated for it
+func (c *column) GenerateColumnType() string {
+	if c.IsJSON() {
+		return "orm.Col"
+	}
+	if c.enums != nil {
+		return CamelCase(c.table.name) + CamelCase(c.name) + "Column"
+	}
+	switch c.prototype {
+	case "string":
+		{
+			return "orm.StringColumn"
+		}
+	case "int32":
+		{
+			return "orm.Int32Column"
+		}
+	case "int64":
+		{
+			return "orm.Int64Column"
+		}
+	case "float":
+		{
+			return "orm.Float32Column"
+		}
+	case "bool":
+		{
+			return "orm.BoolColumn"
+		}
+	case "bytes":
+		{
+			return "orm.BytesColumn"
+		}
+	case "google.protobuf.Timestamp":
+		{
+			return "orm.TimeColumn"
+		}
+	}
+	return "orm.Col"
+}
+
+// GenerateFilterField returns the orm.FilterField which allows the column to be filtered and sorted on from an API request or an empty string if it can't be
+func (c *column) GenerateFilterField() string {
+	var filtertype, operators string
+	values := ""
+	switch {
+	case c.IsJSON():
+		{
+			return ""
+		}
+	case c.enums != nil:
+		{
+			filtertype, operators = "orm.FilterString", "orm.FilterOperatorsEqual"
+			quoted := make([]string, 0)
+			for _, e := range c.enums.enums {
+				quoted = append(quoted, fmt.Sprintf("%q", e.SQLValue()))
+			}
+			values = ", Values: []string{" + strings.Join(quoted, ", ") + "}"
+		}
+	case c.prototype == "string":
+		{
+			filtertype, operators = "orm.FilterString", "orm.FilterOperatorsString"
+		}
+	case c.prototype == "int32" || c.prototype == "int64":
+		{
+			filtertype, operators = "orm.FilterInt", "orm.FilterOperatorsOrdered"
+		}
+	case c.prototype == "float":
+		{
+			filtertype, operators = "orm.FilterFloat", "orm.FilterOperatorsOrdered"
+		}
+	case c.prototype == "bool":
+		{
+			filtertype, operators = "orm.FilterBool", "orm.FilterOperatorsEqual"
+		}
+	case c.IsTimestamp():
+		{
+			filtertype, operators = "orm.FilterTime", "orm.FilterOperatorsOrdered"
+		}
+	default:
+		{
+			return ""
+		}
+	}
+	return fmt.Sprintf("{Name: %q, Type: %s, Operators: %s%s, Nullable: %v, Sortable: true}", c.name, filtertype, operators, values, c.nullable)
+}
+
+// GenerateGroupType returns the comparable type of the column in the key of an aggregate group or an empty string if the column can't be grouped
+func (c *column) GenerateGroupType() string {
+	switch {
+	case c.IsJSON():
+		{
+			return ""
+		}
+	case c.enums != nil:
+		{
+			return CamelCase(c.table.name) + "_" + CamelCase(c.table.name) + CamelCase(c.name)
+		}
+	case c.prototype == "bytes":
+		{
+			return "string"
+		}
+	case c.IsTimestamp():
+		{
+			return "time.Time"
+		}
+	case c.prototype == "orm.Geometry":
+		{
+			return ""
+		}
+	}
+	return c.GenerateVariableType()
+}
+
+// GenerateGroupSetter returns the value of the scanned column for the key of an aggregate group
+func (c *column) GenerateGroupSetter(prefix string) string {
+	switch {
+	case c.prototype == "bytes":
+		{
+			return prefix + c.name + ".String"
+		}
+	case c.IsTimestamp():
+		{
+			return prefix + c.name + ".Time"
+		}
+	}
+	return c.GenerateSQLSetter(prefix)
+}
+
 func (c *column) GenerateNullValue() string {
 	switch c.prototype {
 	case "string":
@@ -271,6 +442,10 @@ func (c *column) GenerateSQLSetter(prefix string) string {
 
 func (c *column) GenerateSQL(prefix string) string {
 	name := CamelCase(c.name)
+	if c.IsTypedJSON() {
+		// encoded into a local variable by GenerateJSONEncode
+		return "_" + c.name
+	}
 	switch c.prototype {
 	case "string":
 		{
@@ -318,6 +493,27 @@ type table struct {
 	columns      []*column
 	protoimports *imports
 	goimports    *imports
+	idstrategy   string
+	softdelete   string
+	createdat    string
+	updatedat    string
+}
+
+// id strategies from the configuration mapped to the orm constant
+var idStrategies = map[string]string{
+	"auto":   "orm.IDStrategyAutoIncrement",
+	"uuid":   "orm.IDStrategyUUID",
+	"uuidv7": "orm.IDStrategyUUIDv7",
+	"ulid":   "orm.IDStrategyULID",
+	"hash":   "orm.IDStrategyHash",
+}
+
+// the length of the string form of the keys generated by each id strategy. the binary form is always 16 bytes
+var idStrategyLengths = map[string]int64{
+	"uuid":   36,
+	"uuidv7": 36,
+	"ulid":   26,
+	"hash":   64,
 }
 
 func (t *table) GetChecksum() *column {
@@ -329,20 +525,206 @@ func (t *table) GetChecksum() *column {
 	return nil
 }
 
-func (t *table) AddColumn(position int64, name string, colkey string, datatype string, columntype string, columndef string, maxlength int64, nullable bool, prototype string, enums *enums) {
+func (t *table) AddColumn(position int64, name string, colkey string, datatype string, columntype string, columndef string, maxlength int64, nullable bool, prototype string, enums *enums, extra string) {
 	t.columns = append(t.columns, &column{
-		position:   position,
-		primarykey: colkey == "PRI",
-		name:       name,
-		datatype:   datatype,
-		columntype: columntype,
-		prototype:  prototype,
-		enums:      enums,
-		table:      t,
-		maxlength:  maxlength,
-		nullable:   nullable,
-		defvalue:   columndef,
+		position:      position,
+		primarykey:    colkey == "PRI",
+		name:          name,
+		datatype:      datatype,
+		columntype:    columntype,
+		prototype:     prototype,
+		enums:         enums,
+		table:         t,
+		maxlength:     maxlength,
+		nullable:      nullable,
+		defvalue:      columndef,
+		autoincrement: strings.Contains(strings.ToLower(extra), "auto_increment"),
 	})
+	if colkey == "PRI" && t.idstrategy == "" && strings.Contains(strings.ToLower(extra), "auto_increment") {
+		t.idstrategy = "auto"
+	}
+}
+
+// GetIDStrategy returns the orm constant for the primary key strategy or an empty string if the caller provides the key
+func (t *table) GetIDStrategy() string {
+	return idStrategies[t.idstrategy]
+}
+
+// GetTypedJSON returns the JSON columns which have been mapped to a proto message
+func (t *table) GetTypedJSON() []*column {
+	columns := make([]*column, 0)
+	for _, column := range t.columns {
+		if column.IsTypedJSON() {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// Configure will apply the table configuration to the discovered columns
+func (t *table) Configure(config *TableConfig) error {
+	if config.IDStrategy != "" {
+		if _, ok := idStrategies[config.IDStrategy]; ok == false {
+			return fmt.Errorf("invalid id strategy %s for table %s", config.IDStrategy, t.name)
+		}
+		pk := t.GetPrimaryKey()
+		if pk == nil {
+			return fmt.Errorf("id strategy configured for table %s which has no primary key", t.name)
+		}
+		switch pk.prototype {
+		case "string":
+			{
+				if config.IDStrategy == "auto" {
+					return fmt.Errorf("id strategy auto requires an integer primary key for table %s", t.name)
+				}
+				if length := idStrategyLengths[config.IDStrategy]; pk.maxlength < length {
+					return fmt.Errorf("id strategy %s requires a primary key of at least %d characters but %s.%s is %s", config.IDStrategy, length, t.name, pk.name, pk.columntype)
+				}
+			}
+		case "bytes":
+			{
+				if config.IDStrategy == "auto" {
+					return fmt.Errorf("id strategy auto requires an integer primary key for table %s", t.name)
+				}
+				// a fixed length binary column pads a shorter key with zeros so it has to be exactly 16 bytes
+				if pk.maxlength < 16 || (pk.datatype == "binary" && pk.maxlength != 16) {
+					return fmt.Errorf("id strategy %s requires a binary(16) primary key but %s.%s is %s", config.IDStrategy, t.name, pk.name, pk.columntype)
+				}
+			}
+		case "int32", "int64":
+			{
+				if config.IDStrategy != "auto" {
+					return fmt.Errorf("id strategy %s requires a string or binary primary key for table %s", config.IDStrategy, t.name)
+				}
+			}
+		default:
+			{
+				return fmt.Errorf("id strategy is not supported for the primary key of table %s", t.name)
+			}
+		}
+		t.idstrategy = config.IDStrategy
+	}
+	if config.SoftDelete != "" {
+		t.softdelete = config.SoftDelete
+		if config.SoftDelete != "-" && t.GetSoftDelete() == nil {
+			return fmt.Errorf("soft delete column %s.%s must be a nullable date or timestamp column", t.name, config.SoftDelete)
+		}
+	}
+	if config.CreatedAt != "" {
+		t.createdat = config.CreatedAt
+		if config.CreatedAt != "-" && t.GetCreatedAt() == nil {
+			return fmt.Errorf("created at column %s.%s must be a date or timestamp column", t.name, config.CreatedAt)
+		}
+	}
+	if config.UpdatedAt != "" {
+		t.updatedat = config.UpdatedAt
+		if config.UpdatedAt != "-" && t.GetUpdatedAt() == nil {
+			return fmt.Errorf("updated at column %s.%s must be a date or timestamp column", t.name, config.UpdatedAt)
+		}
+	}
+	names := make([]string, 0)
+	for name := range config.JSONTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		jsontype := config.JSONTypes[name]
+		var found *column
+		for _, column := range t.columns {
+			if column.name == name {
+				found = column
+				break
+			}
+		}
+		if found == nil {
+			return fmt.Errorf("json type configured for unknown column %s.%s", t.name, name)
+		}
+		if found.IsJSON() == false {
+			return fmt.Errorf("json type configured for column %s.%s which is %s and not json", t.name, name, found.datatype)
+		}
+		if jsontype == nil || (jsontype.Message == "" && jsontype.GoType == "") {
+			return fmt.Errorf("json type for column %s.%s is missing the message name or go type", t.name, name)
+		}
+		if jsontype.Message != "" && jsontype.GoType != "" {
+			return fmt.Errorf("json type for column %s.%s can't have both a message name and a go type", t.name, name)
+		}
+		if jsontype.GoType != "" {
+			// the column stays a string and the generated accessors encode the go type
+			if jsontype.GoImport != "" {
+				t.goimports.Add(jsontype.GoImport)
+			}
+			found.jsontype = jsontype
+			continue
+		}
+		imp := jsontype.Import
+		if imp == "" {
+			imp = wellKnownJSONImports[jsontype.Message]
+		}
+		if imp != "" {
+			t.protoimports.Add(imp)
+		}
+		found.jsontype = jsontype
+		found.prototype = jsontype.Message
+	}
+	return nil
+}
+
+// IsTimestamp returns true if the column is a date or time column
+func (c *column) IsTimestamp() bool {
+	return c.prototype == "google.protobuf.Timestamp"
+}
+
+// GetSoftDelete returns the nullable timestamp column which marks a record as deleted or nil if the table doesn't have one
+func (t *table) GetSoftDelete() *column {
+	if t.softdelete == "-" {
+		return nil
+	}
+	name := t.softdelete
+	if name == "" {
+		name = "deleted_at"
+	}
+	for _, column := range t.columns {
+		if column.name == name && column.primarykey == false && column.nullable && column.IsTimestamp() {
+			return column
+		}
+	}
+	return nil
+}
+
+// getTimestampColumn returns the configured timestamp column or the column with the default name. - turns it off
+func (t *table) getTimestampColumn(name string, defname string) *column {
+	if name == "-" {
+		return nil
+	}
+	if name == "" {
+		name = defname
+	}
+	for _, column := range t.columns {
+		if column.name == name && column.primarykey == false && column.IsTimestamp() {
+			return column
+		}
+	}
+	return nil
+}
+
+// GetCreatedAt returns the timestamp column which is set when a record is created or nil if the table doesn't have one
+func (t *table) GetCreatedAt() *column {
+	return t.getTimestampColumn(t.createdat, "created_at")
+}
+
+// GetUpdatedAt returns the timestamp column which is set whenever a record is written or nil if the table doesn't have one
+func (t *table) GetUpdatedAt() *column {
+	return t.getTimestampColumn(t.updatedat, "updated_at")
+}
+
+// GetVersion returns the integer version column or nil if the table doesn't have one
+func (t *table) GetVersion() *column {
+	for _, column := range t.columns {
+		if column.IsVersion() {
+			return column
+		}
+	}
+	return nil
 }
 
 func (t *table) GetPrimaryKey() *column {
@@ -445,7 +827,8 @@ func DiscoverTables(db *sqlx.DB, schema string) ([]*table, error) {
 		NUMERIC_PRECISION, 
 		NUMERIC_SCALE, 
 		COLUMN_TYPE,
-		ORDINAL_POSITION
+		ORDINAL_POSITION,
+		EXTRA
 	FROM INFORMATION_SCHEMA.COLUMNS 
 	WHERE TABLE_SCHEMA = ? 
 	ORDER BY TABLE_NAME, ORDINAL_POSITION`
@@ -461,9 +844,9 @@ func DiscoverTables(db *sqlx.DB, schema string) ([]*table, error) {
 	var currentTable *table
 
 	for rows.Next() {
-		var tableName, columnName, columnKey, isNullable, dataType, columnType, columnDef sql.NullString
+		var tableName, columnName, columnKey, isNullable, dataType, columnType, columnDef, extra sql.NullString
 		var maxLength, precision, scale, position sql.NullInt64
-		if err := rows.Scan(&tableName, &columnName, &columnKey, &isNullable, &dataType, &columnDef, &maxLength, &precision, &scale, &columnType, &position); err != nil {
+		if err := rows.Scan(&tableName, &columnName, &columnKey, &isNullable, &dataType, &columnDef, &maxLength, &precision, &scale, &columnType, &position, &extra); err != nil {
 			return nil, err
 		}
 		if currentTable == nil || (currentTable != nil && currentTable.name != tableName.String) {
@@ -471,13 +854,18 @@ func DiscoverTables(db *sqlx.DB, schema string) ([]*table, error) {
 			tables = append(tables, currentTable)
 		}
 		field, e := genField(tableName.String, columnName.String, dataType.String, columnType.String, currentTable)
-		currentTable.AddColumn(position.Int64, columnName.String, columnKey.String, dataType.String, columnType.String, columnDef.String, maxLength.Int64, isNullable.String == "YES", field, e)
+		currentTable.AddColumn(position.Int64, columnName.String, columnKey.String, dataType.String, columnType.String, columnDef.String, maxLength.Int64, isNullable.String == "YES", field, e, extra.String)
 	}
 
 	return tables, nil
 }
 
 func Generate(db *sqlx.DB, schema string, packageName string, dirname string) error {
+	return GenerateWithConfig(db, schema, packageName, dirname, nil)
+}
+
+// GenerateWithConfig will generate the files for the schema using the configuration which can be nil
+func GenerateWithConfig(db *sqlx.DB, schema string, packageName string, dirname string, config *Config) error {
 	schemaDir := path.Join(dirname, packageName)
 	if err := os.MkdirAll(schemaDir, 0777); err != nil {
 		return err
@@ -486,6 +874,11 @@ func Generate(db *sqlx.DB, schema string, packageName string, dirname string) er
 	if err != nil {
 		return err
 	}
+	for _, table := range tables {
+		if err := table.Configure(config.Table(table.name)); err != nil {
+			return err
+		}
+	}
 	if err := GenerateTestMain(packageName, schemaDir); err != nil {
 		return err
 	}
@@ -77,11 +77,12 @@ func TestMain(m *testing.M) {
 		// open without a database so we can create a temp one
 		d := openDB("")
 		_, err := d.Exec(fmt.Sprintf("create database %s", database))
+		d.Close()
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			// run the tests which don't need a database such as the generated code checks
+			fmt.Println("skipping the database tests:", err)
+			os.Exit(m.Run())
 		}
-		d.Close()
 	}
 	// reopen now with the temp database
 	db = openDB(database)
@@ -92,6 +93,9 @@ func TestMain(m *testing.M) {
 }
 
 func TestGenerator(t *testing.T) {
+	if db == nil {
+		t.Skip("no test database")
+	}
 	dsn := GetDSN(database)
 	db, err := sqlx.Connect("mysql", dsn)
 	if err != nil {
@@ -22,7 +22,9 @@ func (t *table) pluralize(name string) string {
 }
 
 func (t *table) GenerateORM(packageName string, writer io.Writer) error {
-	buf := bufio.NewWriter(writer)
+	// the body is generated first so that it can add to the imports written in the header
+	var body bytes.Buffer
+	buf := &body
 
 	var cbuf bytes.Buffer
 	n := CamelCase(t.name)
@@ -30,8 +32,19 @@ func (t *table) GenerateORM(packageName string, writer io.Writer) error {
 	sqlprefix := prefix + "."
 	pk := t.GetPrimaryKey()
 	checksum := t.GetChecksum()
+	softdelete := t.GetSoftDelete()
+	version := t.GetVersion()
 	colcount := len(t.columns)
 
+	// typed json columns are encoded before any write so that encoding errors can be returned
+	generateJSONEncode := func(returnvalue string) string {
+		var buf bytes.Buffer
+		for _, column := range t.GetTypedJSON() {
+			buf.WriteString(column.GenerateJSONEncode(sqlprefix, returnvalue))
+		}
+		return buf.String()
+	}
+
 	for _, column := range t.columns {
 		if column.enums != nil {
 			cbuf.WriteString("// write out a helper for serializing alias fields for enums which have special characters\n")
@@ -60,18 +73,28 @@ func (t *table) GenerateORM(packageName string, writer io.Writer) error {
 			cbuf.WriteString("	return " + tn + "(" + enumprefix + "[strings.ToUpper(v)])\n")
 			cbuf.WriteString("}\n\n")
 		}
+		if column.IsGoJSON() {
+			// a plain go type can't be a field of the message so the string column is encoded by accessors
+			gotype := column.jsontype.GoType
+			name := CamelCase(column.name)
+			cbuf.WriteString("// Decode" + name + " returns the " + column.name + " column decoded into a " + gotype + ". the zero value is returned when the column is empty\n")
+			cbuf.WriteString(t.GenerateFuncPrefix(prefix, n, "Decode"+name, "", "("+gotype+", error)"))
+			cbuf.WriteString("\tvar v " + gotype + "\n")
+			cbuf.WriteString("\terr := orm.UnmarshalJSONString(\"" + column.name + "\", " + sqlprefix + name + ", &v)\n")
+			cbuf.WriteString("\treturn v, err\n")
+			cbuf.WriteString("}\n\n")
+			cbuf.WriteString("// Encode" + name + " sets the " + column.name + " column to the JSON encoding of the value\n")
+			cbuf.WriteString(t.GenerateFuncPrefix(prefix, n, "Encode"+name, "v "+gotype, "error"))
+			cbuf.WriteString("\ts, err := orm.MarshalJSONString(\"" + column.name + "\", v)\n")
+			cbuf.WriteString("\tif err != nil {\n")
+			cbuf.WriteString("\t\treturn err\n")
+			cbuf.WriteString("\t}\n")
+			cbuf.WriteString("\t" + sqlprefix + name + " = s\n")
+			cbuf.WriteString("\treturn nil\n")
+			cbuf.WriteString("}\n\n")
+		}
 	}
 
-	buf.WriteString("package " + packageName + ";\n\n")
-	buf.WriteString("import (\n")
-	buf.WriteString("\t\"context\"\n")
-	buf.WriteString("\t\"database/sql\"\n")
-	buf.WriteString("\t\"github.com/jhaynie/dbgen/pkg/orm\"\n")
-	if len(t.goimports.imports) > 0 {
-		buf.WriteString(t.goimports.GoString())
-	}
-	buf.WriteString(")\n\n")
-
 	// write out any definitions
 	buf.Write(cbuf.Bytes())
 
@@ -98,74 +121,206 @@ func (t *table) GenerateORM(packageName string, writer io.Writer) error {
 		buf.WriteString("\n")
 	}
 
-	// INSERT
-	buf.WriteString("// DBCreate will create a new " + CamelCase(t.name) + " record in the database\n")
-	buf.WriteString(t.GenerateFuncPrefix(prefix, n, "DBCreate", "ctx context.Context, db *sql.DB", "(sql.Result, error)"))
-	buf.WriteString("\tq := \"INSERT INTO `" + t.name + "` (")
-	for i, column := range t.columns {
-		buf.WriteString("`" + column.name + "`")
-		if i+1 < colcount {
-			buf.WriteString(",")
+	// field names used for partial updates
+	field := n + "Field"
+	buf.WriteString("// " + field + " is the name of a " + n + " column which can be passed to a partial update\n")
+	buf.WriteString("type " + field + " string\n\n")
+	buf.WriteString("const (\n")
+	for _, column := range t.columns {
+		buf.WriteString("\t// " + field + CamelCase(column.name) + " is the " + column.name + " column\n")
+		buf.WriteString("\t" + field + CamelCase(column.name) + " " + field + " = \"" + column.name + "\"\n")
+	}
+	buf.WriteString(")\n\n")
+	buf.WriteString("// ColumnName returns the name of the column so that the field can be passed to orm.Only\n")
+	buf.WriteString("func (f " + field + ") ColumnName() string {\n")
+	buf.WriteString("\treturn string(f)\n")
+	buf.WriteString("}\n\n")
+
+	// typed columns so that a misspelled column or a value of the wrong type doesn't compile
+	for _, column := range t.columns {
+		if column.enums != nil {
+			ct := column.GenerateColumnType()
+			tn := n + "_" + n + CamelCase(column.name)
+			buf.WriteString("// " + ct + " is the " + column.name + " column of " + n + " whose conditions only accept a " + tn + "\n")
+			buf.WriteString("type " + ct + " struct {\n")
+			buf.WriteString("\torm.Col\n")
+			buf.WriteString("}\n\n")
+			buf.WriteString("// Eq returns a condition which is true when the column is equal to the value\n")
+			buf.WriteString("func (c " + ct + ") Eq(value " + tn + ") orm.ConditionDef {\n")
+			buf.WriteString("\treturn orm.IsEqual(c.Name, value.SQLValue())\n")
+			buf.WriteString("}\n\n")
+			buf.WriteString("// NotEq returns a condition which is true when the column isn't equal to the value\n")
+			buf.WriteString("func (c " + ct + ") NotEq(value " + tn + ") orm.ConditionDef {\n")
+			buf.WriteString("\treturn orm.IsNotEqual(c.Name, value.SQLValue())\n")
+			buf.WriteString("}\n\n")
+			buf.WriteString("// In returns a condition which is true when the column is one of the values\n")
+			buf.WriteString("func (c " + ct + ") In(values ..." + tn + ") orm.ConditionDef {\n")
+			buf.WriteString("\treturn orm.IsIn(c.Name, c.values(values))\n")
+			buf.WriteString("}\n\n")
+			buf.WriteString("// NotIn returns a condition which is true when the column isn't any of the values\n")
+			buf.WriteString("func (c " + ct + ") NotIn(values ..." + tn + ") orm.ConditionDef {\n")
+			buf.WriteString("\treturn orm.IsNotIn(c.Name, c.values(values))\n")
+			buf.WriteString("}\n\n")
+			buf.WriteString("// Set returns an assignment of the value to the column\n")
+			buf.WriteString("func (c " + ct + ") Set(value " + tn + ") orm.AssignmentDef {\n")
+			buf.WriteString("\treturn orm.Set(c.Name, value.SQLValue())\n")
+			buf.WriteString("}\n\n")
+			buf.WriteString("func (c " + ct + ") values(values []" + tn + ") []interface{} {\n")
+			buf.WriteString("\tresult := make([]interface{}, len(values))\n")
+			buf.WriteString("\tfor i, v := range values {\n")
+			buf.WriteString("\t\tresult[i] = v.SQLValue()\n")
+			buf.WriteString("\t}\n")
+			buf.WriteString("\treturn result\n")
+			buf.WriteString("}\n\n")
 		}
 	}
-	buf.WriteString(") VALUES (")
-	for i, column := range t.columns {
-		buf.WriteString(column.GenerateSQLPlaceholder())
-		if i+1 < colcount {
-			buf.WriteString(",")
+	columns := n + "Columns"
+	columnsType := prefix + "Columns"
+	buf.WriteString("// " + columnsType + " has a typed column for each " + n + " column\n")
+	buf.WriteString("type " + columnsType + " struct {\n")
+	for _, column := range t.columns {
+		buf.WriteString("\t" + CamelCase(column.name) + " " + column.GenerateColumnType() + "\n")
+	}
+	buf.WriteString("}\n\n")
+	buf.WriteString("// " + columns + " has the typed " + n + " columns which build conditions, orders and assignments\n")
+	buf.WriteString("var " + columns + " = " + columnsType + "{\n")
+	for _, column := range t.columns {
+		ct := column.GenerateColumnType()
+		if ct == "orm.Col" {
+			buf.WriteString("\t" + CamelCase(column.name) + ": orm.Col{Name: \"" + column.name + "\"},\n")
+		} else {
+			buf.WriteString("\t" + CamelCase(column.name) + ": " + ct + "{Col: orm.Col{Name: \"" + column.name + "\"}},\n")
 		}
 	}
-	buf.WriteString(")\"\n")
-	buf.WriteString("\treturn db.ExecContext(ctx, q,\n")
+	buf.WriteString("}\n\n")
+
+	// the columns and operators which can be used in the filters of an API request
+	buf.WriteString("// " + n + "FilterSchema has the " + n + " columns and operators which can be parsed from the filters of an API request. use Only or Without to limit the columns which are exposed\n")
+	buf.WriteString("var " + n + "FilterSchema = orm.FilterSchema{\n")
+	buf.WriteString("\tFields: []orm.FilterField{\n")
 	for _, column := range t.columns {
-		if column.IsChecksum() {
-			buf.WriteString("\t\t" + prefix + ".CalculateChecksum()")
+		if f := column.GenerateFilterField(); f != "" {
+			buf.WriteString("\t\t" + f + ",\n")
+		}
+	}
+	buf.WriteString("\t},\n")
+	buf.WriteString("}\n\n")
+
+	// fill in the primary key before an insert if the table has an id strategy
+	idstrategy := t.GetIDStrategy()
+	generateCreateKey := func() string {
+		var buf bytes.Buffer
+		if pk == nil || idstrategy == "" || t.idstrategy == "auto" {
+			return ""
+		}
+		name := sqlprefix + CamelCase(pk.name)
+		if pk.prototype == "bytes" {
+			buf.WriteString("\tif len(" + name + ") == 0 {\n")
+			buf.WriteString("\t\t" + name + " = orm.NewIDBytes(" + idstrategy + ")\n")
 		} else {
-			buf.WriteString("\t\t" + column.GenerateSQL(sqlprefix))
+			buf.WriteString("\tif " + name + " == \"\" {\n")
+			buf.WriteString("\t\t" + name + " = orm.NewID(" + idstrategy + ")\n")
 		}
-		buf.WriteString(",")
+		buf.WriteString("\t}\n")
+		return buf.String()
+	}
+
+	// lifecycle hooks are called when the model implements the orm interface for them
+	generateHook := func(hook string, handle string, model string, returnvalue string, indent string) string {
+		var buf bytes.Buffer
+		buf.WriteString(indent + "if err := orm.Run" + hook + "(ctx, " + handle + ", " + model + "); err != nil {\n")
+		buf.WriteString(indent + "\treturn " + returnvalue + ", err\n")
+		buf.WriteString(indent + "}\n")
+		return buf.String()
+	}
+
+	// each operation takes an orm.Executor and the Tx variants are kept as thin shims for compatibility
+	generateMethodShim := func(name string, comment string, params string, args string, returnvalue string) {
+		buf.WriteString("// " + name + "Tx " + comment + "\n")
+		buf.WriteString("//\n")
+		buf.WriteString("// Deprecated: use " + name + " which accepts a *sql.Tx as the executor\n")
+		buf.WriteString(t.GenerateFuncPrefix(prefix, n, name+"Tx", "ctx context.Context, tx *sql.Tx"+params, returnvalue))
+		buf.WriteString("\treturn " + prefix + "." + name + "(ctx, tx" + args + ")\n")
+		buf.WriteString("}\n")
+		buf.WriteString("\n")
+	}
+	generateFuncShim := func(name string, comment string, params string, args string, returnvalue string) {
+		buf.WriteString("// " + name + "Tx " + comment + "\n")
+		buf.WriteString("//\n")
+		buf.WriteString("// Deprecated: use " + name + " which accepts a *sql.Tx as the executor\n")
+		buf.WriteString("func " + name + "Tx(ctx context.Context, tx *sql.Tx" + params + ") " + returnvalue + " {\n")
+		buf.WriteString("\treturn " + name + "(ctx, tx" + args + ")\n")
+		buf.WriteString("}\n")
 		buf.WriteString("\n")
 	}
-	buf.WriteString("\t)\n")
-	buf.WriteString("}\n")
-	buf.WriteString("\n")
 
-	// INSERT with TX
-	buf.WriteString("// DBCreateTx will create a new " + CamelCase(t.name) + " record in the database within an existing transaction\n")
-	buf.WriteString(t.GenerateFuncPrefix(prefix, n, "DBCreateTx", "ctx context.Context, tx *sql.Tx", "(sql.Result, error)"))
-	buf.WriteString("\tq := \"INSERT INTO `" + t.name + "` (")
-	for i, column := range t.columns {
-		buf.WriteString("`" + column.name + "`")
-		if i+1 < colcount {
-			buf.WriteString(",")
+	// managed timestamps are set from the orm clock before a write
+	createdat := t.GetCreatedAt()
+	updatedat := t.GetUpdatedAt()
+	generateTimestamps := func(create bool) string {
+		var buf bytes.Buffer
+		if (create == false || createdat == nil) && updatedat == nil {
+			return ""
 		}
-	}
-	buf.WriteString(") VALUES (")
-	for i, column := range t.columns {
-		buf.WriteString(column.GenerateSQLPlaceholder())
-		if i+1 < colcount {
-			buf.WriteString(",")
+		buf.WriteString("\t_now := orm.Now()\n")
+		if create && createdat != nil {
+			buf.WriteString("\tif " + sqlprefix + CamelCase(createdat.name) + " == nil {\n")
+			buf.WriteString("\t\t" + sqlprefix + CamelCase(createdat.name) + " = orm.ToTimestampTime(_now)\n")
+			buf.WriteString("\t}\n")
+		}
+		if updatedat != nil {
+			buf.WriteString("\t" + sqlprefix + CamelCase(updatedat.name) + " = orm.ToTimestampTime(_now)\n")
 		}
+		return buf.String()
 	}
-	buf.WriteString(")\"\n")
-	buf.WriteString("\treturn tx.ExecContext(ctx, q,\n")
+
+	// the created timestamp of an existing record is kept by an upsert and its version is incremented
+	upsertsets := make([]string, 0)
 	for _, column := range t.columns {
+		if column == version {
+			upsertsets = append(upsertsets, "`"+column.name+"` = `"+column.name+"` + 1")
+		} else if column.primarykey == false && column != createdat {
+			upsertsets = append(upsertsets, "`"+column.name+"` = VALUES(`"+column.name+"`)")
+		}
+	}
+
+	// the value written by an insert for the column. a zero auto increment key is written as NULL so that the database assigns it
+	generateInsertValue := func(column *column) string {
 		if column.IsChecksum() {
-			buf.WriteString("\t\t" + prefix + ".CalculateChecksum()")
-		} else {
-			buf.WriteString("\t\t" + column.GenerateSQL(sqlprefix))
+			return prefix + ".CalculateChecksum()"
 		}
-		buf.WriteString(",")
-		buf.WriteString("\n")
+		if column.primarykey && t.idstrategy == "auto" {
+			return "orm.ToSQLAutoIncrement(" + sqlprefix + CamelCase(column.name) + ")"
+		}
+		return column.GenerateSQL(sqlprefix)
 	}
-	buf.WriteString("\t)\n")
-	buf.WriteString("}\n")
-	buf.WriteString("\n")
 
-	if pk != nil {
-		// INSERT WITH IGNORING DUPLICATE KEY (acts like an upsert w/o a transaction)
-		buf.WriteString("// DBCreateIgnoreDuplicate will create a new " + CamelCase(t.name) + " record in the database and will ignore duplicate key exception (acts like an upsert without a transaction)\n")
-		buf.WriteString(t.GenerateFuncPrefix(prefix, n, "DBCreateIgnoreDuplicate", "ctx context.Context, db *sql.DB", "(sql.Result, error)"))
+	// a single row insert reads back the key assigned by the database
+	generateLastInsertID := func(returnvalue string) string {
+		var buf bytes.Buffer
+		if pk == nil || t.idstrategy != "auto" {
+			return ""
+		}
+		name := sqlprefix + CamelCase(pk.name)
+		buf.WriteString("\tif " + name + " == 0 {\n")
+		buf.WriteString("\t\tid, err := r.LastInsertId()\n")
+		buf.WriteString("\t\tif err != nil {\n")
+		buf.WriteString("\t\t\treturn " + returnvalue + ", err\n")
+		buf.WriteString("\t\t}\n")
+		buf.WriteString("\t\t" + name + " = " + pk.GenerateCast("id") + "\n")
+		buf.WriteString("\t}\n")
+		return buf.String()
+	}
+
+	// LAST_INSERT_ID(key) makes the key of an existing row the last insert id when an insert becomes an update
+	var lastinsertset string
+	if pk != nil && t.idstrategy == "auto" {
+		lastinsertset = "`" + pk.name + "` = LAST_INSERT_ID(`" + pk.name + "`)"
+	}
+
+	generateCreate := func(name string, params string, handle string, comment string) {
+		buf.WriteString("// " + name + " will create a new " + CamelCase(t.name) + " record in the database" + comment + "\n")
+		buf.WriteString(t.GenerateFuncPrefix(prefix, n, name, params, "(sql.Result, error)"))
 		buf.WriteString("\tq := \"INSERT INTO `" + t.name + "` (")
 		for i, column := range t.columns {
 			buf.WriteString("`" + column.name + "`")
@@ -180,52 +335,91 @@ func (t *table) GenerateORM(packageName string, writer io.Writer) error {
 				buf.WriteString(",")
 			}
 		}
-		buf.WriteString(") ON DUPLICATE KEY UPDATE `" + pk.name + "` = `" + pk.name + "`\"\n")
-		buf.WriteString("\treturn db.ExecContext(ctx, q,\n")
+		buf.WriteString(")\"\n")
+		buf.WriteString(generateHook("BeforeCreate", handle, prefix, "nil", "\t"))
+		buf.WriteString(generateCreateKey())
+		buf.WriteString(generateTimestamps(true))
+		buf.WriteString(generateJSONEncode("nil"))
+		buf.WriteString("\tr, err := " + handle + ".ExecContext(ctx, q,\n")
 		for _, column := range t.columns {
-			if column.IsChecksum() {
-				buf.WriteString("\t\t" + prefix + ".CalculateChecksum()")
-			} else {
-				buf.WriteString("\t\t" + column.GenerateSQL(sqlprefix))
-			}
-			buf.WriteString(",")
-			buf.WriteString("\n")
+			buf.WriteString("\t\t" + generateInsertValue(column) + ",\n")
 		}
 		buf.WriteString("\t)\n")
+		buf.WriteString("\tif err != nil {\n")
+		buf.WriteString("\t\treturn nil, orm.WrapError(err)\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString(generateLastInsertID("nil"))
+		buf.WriteString(generateHook("AfterCreate", handle, prefix, "nil", "\t"))
+		buf.WriteString("\treturn r, nil\n")
 		buf.WriteString("}\n")
 		buf.WriteString("\n")
+	}
 
-		// INSERT WITH IGNORING DUPLICATE KEY and Tx
-		buf.WriteString("// DBCreateIgnoreDuplicateTx will create a new " + CamelCase(t.name) + " record in the database and will ignore duplicate key exception (acts like an upsert without a transaction)\n")
-		buf.WriteString(t.GenerateFuncPrefix(prefix, n, "DBCreateIgnoreDuplicateTx", "ctx context.Context, tx *sql.Tx", "(sql.Result, error)"))
-		buf.WriteString("\tq := \"INSERT INTO `" + t.name + "` (")
-		for i, column := range t.columns {
-			buf.WriteString("`" + column.name + "`")
-			if i+1 < colcount {
-				buf.WriteString(",")
+	// an update which doesn't check the version still increments it so that a concurrent optimistic update conflicts
+	var versionset, versionincrement, versionComment string
+	if version != nil {
+		versionComment = ". the " + version.name + " column is incremented"
+		versionset = ", `" + version.name + "` = `" + version.name + "` + 1"
+		versionincrement = "\t" + sqlprefix + CamelCase(version.name) + "++\n"
+	}
+
+	// INSERT
+	generateCreate("DBCreate", "ctx context.Context, db orm.Executor", "db", "")
+
+	// INSERT with TX
+	generateMethodShim("DBCreate", "will create a new "+n+" record in the database within an existing transaction", "", "", "(sql.Result, error)")
+
+	if pk != nil {
+		generateCreateIgnoreDuplicate := func(name string, params string, handle string, comment string) {
+			var keyComment string
+			if lastinsertset != "" {
+				keyComment = ". the key is set to the key of the existing record when it's a duplicate"
 			}
-		}
-		buf.WriteString(") VALUES (")
-		for i, column := range t.columns {
-			buf.WriteString(column.GenerateSQLPlaceholder())
-			if i+1 < colcount {
-				buf.WriteString(",")
+			buf.WriteString("// " + name + " will create a new " + CamelCase(t.name) + " record in the database" + comment + " and will ignore duplicate key exception (acts like an upsert without a transaction). use DBCreate and orm.ErrDuplicateKey to detect the duplicate instead" + keyComment + "\n")
+			buf.WriteString(t.GenerateFuncPrefix(prefix, n, name, params, "(sql.Result, error)"))
+			buf.WriteString("\tq := \"INSERT INTO `" + t.name + "` (")
+			for i, column := range t.columns {
+				buf.WriteString("`" + column.name + "`")
+				if i+1 < colcount {
+					buf.WriteString(",")
+				}
 			}
-		}
-		buf.WriteString(") ON DUPLICATE KEY UPDATE `" + pk.name + "` = `" + pk.name + "`\"\n")
-		buf.WriteString("\treturn tx.ExecContext(ctx, q,\n")
-		for _, column := range t.columns {
-			if column.IsChecksum() {
-				buf.WriteString("\t\t" + prefix + ".CalculateChecksum()")
+			buf.WriteString(") VALUES (")
+			for i, column := range t.columns {
+				buf.WriteString(column.GenerateSQLPlaceholder())
+				if i+1 < colcount {
+					buf.WriteString(",")
+				}
+			}
+			if lastinsertset != "" {
+				buf.WriteString(") ON DUPLICATE KEY UPDATE " + lastinsertset + "\"\n")
 			} else {
-				buf.WriteString("\t\t" + column.GenerateSQL(sqlprefix))
+				buf.WriteString(") ON DUPLICATE KEY UPDATE `" + pk.name + "` = `" + pk.name + "`\"\n")
 			}
-			buf.WriteString(",")
+			buf.WriteString(generateHook("BeforeCreate", handle, prefix, "nil", "\t"))
+			buf.WriteString(generateCreateKey())
+			buf.WriteString(generateTimestamps(true))
+			buf.WriteString(generateJSONEncode("nil"))
+			buf.WriteString("\tr, err := " + handle + ".ExecContext(ctx, q,\n")
+			for _, column := range t.columns {
+				buf.WriteString("\t\t" + generateInsertValue(column) + ",\n")
+			}
+			buf.WriteString("\t)\n")
+			buf.WriteString("\tif err != nil {\n")
+			buf.WriteString("\t\treturn nil, orm.WrapError(err)\n")
+			buf.WriteString("\t}\n")
+			buf.WriteString(generateLastInsertID("nil"))
+			buf.WriteString(generateHook("AfterCreate", handle, prefix, "nil", "\t"))
+			buf.WriteString("\treturn r, nil\n")
+			buf.WriteString("}\n")
 			buf.WriteString("\n")
 		}
-		buf.WriteString("\t)\n")
-		buf.WriteString("}\n")
-		buf.WriteString("\n")
+
+		// INSERT WITH IGNORING DUPLICATE KEY (acts like an upsert w/o a transaction)
+		generateCreateIgnoreDuplicate("DBCreateIgnoreDuplicate", "ctx context.Context, db orm.Executor", "db", "")
+
+		// INSERT WITH IGNORING DUPLICATE KEY and Tx
+		generateMethodShim("DBCreateIgnoreDuplicate", "will create a new "+n+" record in the database within an existing transaction and will ignore duplicate key exception", "", "", "(sql.Result, error)")
 	}
 
 	generateScan := func(name string, indent string, returnstr string) string {
@@ -233,13 +427,12 @@ func (t *table) GenerateORM(packageName string, writer io.Writer) error {
 		for _, column := range t.columns {
 			buf.WriteString(indent + "\tvar _" + column.name + " " + column.GetSQLType() + "\n")
 		}
-		buf.WriteString(indent + "\terr := " + name + ".Scan(\n")
+		buf.WriteString(indent + "\tif err := " + name + ".Scan(\n")
 		for _, column := range t.columns {
 			buf.WriteString(indent + "\t\t&_" + column.name + ",\n")
 		}
-		buf.WriteString(indent + "\t)\n")
-		buf.WriteString(indent + "\tif err != nil && err != sql.ErrNoRows {\n")
-		buf.WriteString(indent + "\t\treturn " + returnstr + ", err\n")
+		buf.WriteString(indent + "\t); err != nil && err != sql.ErrNoRows {\n")
+		buf.WriteString(indent + "\t\treturn " + returnstr + ", orm.WrapError(err)\n")
 		buf.WriteString(indent + "\t}\n")
 		if pk != nil {
 			buf.WriteString(indent + "\tif _" + pk.name + ".Valid == false {\n")
@@ -247,264 +440,654 @@ func (t *table) GenerateORM(packageName string, writer io.Writer) error {
 			buf.WriteString(indent + "\t}\n")
 		}
 		for _, column := range t.columns {
+			if column.IsTypedJSON() {
+				buf.WriteString(column.GenerateJSONDecode(sqlprefix, indent+"\t", returnstr))
+				continue
+			}
 			buf.WriteString(indent + "\t" + prefix + "." + CamelCase(column.name) + " = " + column.GenerateSQLSetter("_") + "\n")
 		}
 		return buf.String()
 	}
 
 	if pk != nil {
-		// UPDATE
-		buf.WriteString("// DBUpdate will update the " + n + " record in the database\n")
-		buf.WriteString(t.GenerateFuncPrefix(prefix, n, "DBUpdate", "ctx context.Context, db *sql.DB", "(sql.Result, error)"))
-		if checksum != nil {
-			buf.WriteString("\tdirty, checksum := " + prefix + ".DBIsDirty()\n")
-			buf.WriteString("\tif dirty == false {\n")
-			buf.WriteString("\t\treturn nil, nil\n")
-			buf.WriteString("\t}\n")
-			buf.WriteString("\t" + prefix + "." + CamelCase(checksum.name) + " = checksum\n")
-		}
-		buf.WriteString("\tq := \"UPDATE `" + t.name + "` SET ")
-		for i, column := range t.columns {
-			if column.primarykey == false {
-				buf.WriteString("`" + column.name + "` = ")
-				buf.WriteString(column.GenerateSQLPlaceholder())
-				if i+1 < colcount {
-					buf.WriteString(", ")
+		generateUpdate := func(name string, params string, handle string, comment string) {
+			buf.WriteString("// " + name + " will update the " + n + " record in the database" + comment + versionComment + "\n")
+			buf.WriteString(t.GenerateFuncPrefix(prefix, n, name, params, "(sql.Result, error)"))
+			buf.WriteString(generateHook("BeforeUpdate", handle, prefix, "nil", "\t"))
+			if checksum != nil {
+				buf.WriteString("\tdirty, checksum := " + prefix + ".DBIsDirty()\n")
+				buf.WriteString("\tif dirty == false {\n")
+				buf.WriteString("\t\treturn nil, nil\n")
+				buf.WriteString("\t}\n")
+			}
+			buf.WriteString(generateJSONEncode("nil"))
+			if version != nil {
+				// the version is incremented in the database so that a concurrent optimistic update conflicts
+				buf.WriteString("\t_version := " + sqlprefix + CamelCase(version.name) + "\n")
+				buf.WriteString("\t" + sqlprefix + CamelCase(version.name) + " = _version + 1\n")
+			}
+			buf.WriteString(generateTimestamps(false))
+			if checksum != nil {
+				if version != nil || updatedat != nil {
+					// the checksum includes the version and updated timestamp so it needs to be calculated after they're set
+					buf.WriteString("\tchecksum = " + prefix + ".CalculateChecksum()\n")
 				}
+				buf.WriteString("\t" + prefix + "." + CamelCase(checksum.name) + " = checksum\n")
 			}
-		}
-		buf.WriteString(" WHERE `" + pk.name + "` = ?\"\n")
-		buf.WriteString("\treturn db.ExecContext(ctx, q,\n")
-		for _, column := range t.columns {
-			if column.primarykey == false {
-				buf.WriteString("\t\t" + column.GenerateSQL(sqlprefix) + ",\n")
+			sets := make([]string, 0)
+			for _, column := range t.columns {
+				if column == version {
+					sets = append(sets, "`"+column.name+"` = `"+column.name+"` + 1")
+				} else if column.primarykey == false {
+					sets = append(sets, "`"+column.name+"` = "+column.GenerateSQLPlaceholder())
+				}
+			}
+			buf.WriteString("\tq := \"UPDATE `" + t.name + "` SET " + strings.Join(sets, ", "))
+			buf.WriteString(" WHERE `" + pk.name + "` = ?\"\n")
+			buf.WriteString("\tr, err := " + handle + ".ExecContext(ctx, q,\n")
+			for _, column := range t.columns {
+				if column.primarykey == false && column != version {
+					buf.WriteString("\t\t" + column.GenerateSQL(sqlprefix) + ",\n")
+				}
+			}
+			buf.WriteString("\t\t" + pk.GenerateSQL(sqlprefix) + ",\n")
+			buf.WriteString("\t)\n")
+			buf.WriteString("\tif err != nil {\n")
+			if version != nil {
+				buf.WriteString("\t\t" + sqlprefix + CamelCase(version.name) + " = _version\n")
 			}
+			buf.WriteString("\t\treturn nil, orm.WrapError(err)\n")
+			buf.WriteString("\t}\n")
+			buf.WriteString(generateHook("AfterUpdate", handle, prefix, "nil", "\t"))
+			buf.WriteString("\treturn r, nil\n")
+			buf.WriteString("}\n")
+			buf.WriteString("\n")
 		}
-		buf.WriteString("\t\t" + pk.GenerateSQL(sqlprefix) + ",\n")
-		buf.WriteString("\t)\n")
-		buf.WriteString("}\n")
-		buf.WriteString("\n")
+
+		// UPDATE
+		generateUpdate("DBUpdate", "ctx context.Context, db orm.Executor", "db", "")
 
 		// UPDATE with Tx
-		buf.WriteString("// DBUpdateTx will update the " + n + " record in the database within an existing transaction\n")
-		buf.WriteString(t.GenerateFuncPrefix(prefix, n, "DBUpdateTx", "ctx context.Context, tx *sql.Tx", "(sql.Result, error)"))
-		if checksum != nil {
-			buf.WriteString("\tdirty, checksum := " + prefix + ".DBIsDirty()\n")
-			buf.WriteString("\tif dirty == false {\n")
-			buf.WriteString("\t\treturn nil, nil\n")
+		generateMethodShim("DBUpdate", "will update the "+n+" record in the database within an existing transaction", "", "", "(sql.Result, error)")
+
+		// the key of a deleted row is reset after the AfterDelete hook so that the hook can tell which record was deleted
+		generateAfterHardDelete := func(handle string) string {
+			var buf bytes.Buffer
+			buf.WriteString("\terr = orm.RunAfterDelete(ctx, " + handle + ", " + prefix + ")\n")
+			buf.WriteString("\t" + sqlprefix + CamelCase(pk.name) + " = " + pk.GenerateNullValue() + "\n")
+			buf.WriteString("\treturn true, err\n")
+			return buf.String()
+		}
+
+		generateDelete := func(name string, params string, handle string, comment string) {
+			buf.WriteString("// " + name + " will delete the " + n + " record in the database" + comment + "\n")
+			buf.WriteString(t.GenerateFuncPrefix(prefix, n, name, params, "(bool, error)"))
+			buf.WriteString(generateHook("BeforeDelete", handle, prefix, "false", "\t"))
+			buf.WriteString("\tq := \"DELETE FROM `" + t.name + "` WHERE `" + pk.name + "` = ?\"\n")
+			buf.WriteString("\tr, err := " + handle + ".ExecContext(ctx, q, " + pk.GenerateSQL(sqlprefix) + ")\n")
+			buf.WriteString("\tif err != nil && err != sql.ErrNoRows {\n")
+			buf.WriteString("\t\treturn false, orm.WrapError(err)\n")
 			buf.WriteString("\t}\n")
-			buf.WriteString("\t" + prefix + "." + CamelCase(checksum.name) + " = checksum\n")
+			buf.WriteString("\trows, err := r.RowsAffected()\n")
+			buf.WriteString("\tif err != nil {\n")
+			buf.WriteString("\t\treturn false, err\n")
+			buf.WriteString("\t}\n")
+			buf.WriteString("\tif rows == 0 {\n")
+			buf.WriteString("\t\treturn false, nil\n")
+			buf.WriteString("\t}\n")
+			buf.WriteString(generateAfterHardDelete(handle))
+			buf.WriteString("}\n")
+			buf.WriteString("\n")
 		}
-		buf.WriteString("\tq := \"UPDATE `" + t.name + "` SET ")
-		for i, column := range t.columns {
-			if column.primarykey == false {
-				buf.WriteString("`" + column.name + "` = ")
-				buf.WriteString(column.GenerateSQLPlaceholder())
-				if i+1 < colcount {
-					buf.WriteString(", ")
+
+		generateSoftDelete := func(name string, params string, handle string, comment string) {
+			field := sqlprefix + CamelCase(softdelete.name)
+			buf.WriteString("// " + name + " will mark the " + n + " record as deleted by setting the " + softdelete.name + " column" + comment + ". it returns false if the record was already deleted" + versionComment + "\n")
+			buf.WriteString(t.GenerateFuncPrefix(prefix, n, name, params, "(bool, error)"))
+			buf.WriteString(generateHook("BeforeDelete", handle, prefix, "false", "\t"))
+			buf.WriteString("\t_" + softdelete.name + " := " + field + "\n")
+			buf.WriteString("\t" + field + " = orm.ToTimestampNow()\n")
+			buf.WriteString("\tq := \"UPDATE `" + t.name + "` SET `" + softdelete.name + "` = ?" + versionset + " WHERE `" + pk.name + "` = ? AND `" + softdelete.name + "` IS NULL\"\n")
+			buf.WriteString("\tr, err := " + handle + ".ExecContext(ctx, q, " + softdelete.GenerateSQL(sqlprefix) + ", " + pk.GenerateSQL(sqlprefix) + ")\n")
+			buf.WriteString("\tvar rows int64\n")
+			buf.WriteString("\tif err == nil {\n")
+			buf.WriteString("\t\trows, err = r.RowsAffected()\n")
+			buf.WriteString("\t}\n")
+			buf.WriteString("\tif err != nil || rows == 0 {\n")
+			buf.WriteString("\t\t" + field + " = _" + softdelete.name + "\n")
+			buf.WriteString("\t\treturn false, orm.WrapError(err)\n")
+			buf.WriteString("\t}\n")
+			buf.WriteString(versionincrement)
+			buf.WriteString(generateHook("AfterDelete", handle, prefix, "true", "\t"))
+			buf.WriteString("\treturn true, nil\n")
+			buf.WriteString("}\n")
+			buf.WriteString("\n")
+		}
+
+		if softdelete != nil {
+			// DELETE by setting the soft delete column
+			generateSoftDelete("DBDelete", "ctx context.Context, db orm.Executor", "db", "")
+
+			// DELETE by setting the soft delete column with Tx
+			generateMethodShim("DBDelete", "will mark the "+n+" record as deleted by setting the "+softdelete.name+" column within an existing transaction", "", "", "(bool, error)")
+
+			// DELETE the row
+			generateDelete("DBHardDelete", "ctx context.Context, db orm.Executor", "db", " even if it has a soft delete column")
+
+			// DELETE the row with Tx
+			generateMethodShim("DBHardDelete", "will delete the "+n+" record in the database within an existing transaction even if it has a soft delete column", "", "", "(bool, error)")
+		} else {
+			// DELETE
+			generateDelete("DBDelete", "ctx context.Context, db orm.Executor", "db", "")
+
+			// DELETE Tx
+			generateMethodShim("DBDelete", "will delete the "+n+" record in the database within an existing transaction", "", "", "(bool, error)")
+		}
+
+		if version != nil || checksum != nil {
+			// optimistic concurrency prefers the version column and falls back to the checksum
+			lock := checksum
+			if version != nil {
+				lock = version
+			}
+			generateUpdateOptimistic := func(name string, params string, handle string, comment string) {
+				buf.WriteString("// " + name + " will update the " + n + " record in the database only if it hasn't changed since it was read based on the " + lock.name + " column" + comment + ". orm.ErrConflict is returned if the record was changed or deleted\n")
+				buf.WriteString(t.GenerateFuncPrefix(prefix, n, name, params, "(sql.Result, error)"))
+				buf.WriteString(generateHook("BeforeUpdate", handle, prefix, "nil", "\t"))
+				if checksum != nil {
+					buf.WriteString("\tdirty, checksum := " + prefix + ".DBIsDirty()\n")
+					buf.WriteString("\tif dirty == false {\n")
+					buf.WriteString("\t\treturn nil, nil\n")
+					buf.WriteString("\t}\n")
+				}
+				if version != nil {
+					buf.WriteString("\t_version := " + sqlprefix + CamelCase(version.name) + "\n")
+					buf.WriteString("\t" + sqlprefix + CamelCase(version.name) + " = _version + 1\n")
+				}
+				if updatedat != nil {
+					buf.WriteString("\t_" + updatedat.name + " := " + sqlprefix + CamelCase(updatedat.name) + "\n")
+					buf.WriteString(generateTimestamps(false))
+				}
+				if checksum != nil && (version != nil || updatedat != nil) {
+					// the checksum includes the version and updated timestamp so it needs to be calculated after they're set
+					buf.WriteString("\tchecksum = " + prefix + ".CalculateChecksum()\n")
+				}
+				if version == nil {
+					buf.WriteString("\t_checksum := " + sqlprefix + CamelCase(checksum.name) + "\n")
+				}
+				sets := make([]string, 0)
+				for _, column := range t.columns {
+					if column.primarykey == false {
+						sets = append(sets, "`"+column.name+"` = "+column.GenerateSQLPlaceholder())
+					}
+				}
+				buf.WriteString("\tq := \"UPDATE `" + t.name + "` SET " + strings.Join(sets, ", "))
+				buf.WriteString(" WHERE `" + pk.name + "` = ? AND `" + lock.name + "` = ?\"\n")
+				buf.WriteString(generateJSONEncode("nil"))
+				buf.WriteString("\tr, err := " + handle + ".ExecContext(ctx, q,\n")
+				for _, column := range t.columns {
+					if column.primarykey {
+						continue
+					}
+					if column.IsChecksum() {
+						buf.WriteString("\t\tchecksum,\n")
+					} else {
+						buf.WriteString("\t\t" + column.GenerateSQL(sqlprefix) + ",\n")
+					}
+				}
+				buf.WriteString("\t\t" + pk.GenerateSQL(sqlprefix) + ",\n")
+				buf.WriteString("\t\t_" + lock.name + ",\n")
+				buf.WriteString("\t)\n")
+				buf.WriteString("\tif err == nil {\n")
+				buf.WriteString("\t\tvar rows int64\n")
+				buf.WriteString("\t\trows, err = r.RowsAffected()\n")
+				buf.WriteString("\t\tif err == nil && rows == 0 {\n")
+				buf.WriteString("\t\t\terr = orm.ErrConflict\n")
+				buf.WriteString("\t\t}\n")
+				buf.WriteString("\t}\n")
+				buf.WriteString("\tif err != nil {\n")
+				if version != nil {
+					buf.WriteString("\t\t" + sqlprefix + CamelCase(version.name) + " = _version\n")
+				}
+				if updatedat != nil {
+					buf.WriteString("\t\t" + sqlprefix + CamelCase(updatedat.name) + " = _" + updatedat.name + "\n")
+				}
+				buf.WriteString("\t\treturn nil, orm.WrapError(err)\n")
+				buf.WriteString("\t}\n")
+				if checksum != nil {
+					buf.WriteString("\t" + sqlprefix + CamelCase(checksum.name) + " = checksum\n")
+				}
+				buf.WriteString(generateHook("AfterUpdate", handle, prefix, "nil", "\t"))
+				buf.WriteString("\treturn r, nil\n")
+				buf.WriteString("}\n")
+				buf.WriteString("\n")
+			}
+
+			generateDeleteOptimistic := func(name string, params string, handle string, comment string) {
+				action := "delete the " + n + " record in the database"
+				if softdelete != nil {
+					action = "mark the " + n + " record as deleted by setting the " + softdelete.name + " column"
 				}
+				buf.WriteString("// " + name + " will " + action + " only if it hasn't changed since it was read based on the " + lock.name + " column" + comment + ". orm.ErrConflict is returned if the record was changed or deleted\n")
+				buf.WriteString(t.GenerateFuncPrefix(prefix, n, name, params, "(bool, error)"))
+				buf.WriteString(generateHook("BeforeDelete", handle, prefix, "false", "\t"))
+				if softdelete != nil {
+					field := sqlprefix + CamelCase(softdelete.name)
+					buf.WriteString("\t_" + softdelete.name + " := " + field + "\n")
+					buf.WriteString("\t" + field + " = orm.ToTimestampNow()\n")
+					buf.WriteString("\tq := \"UPDATE `" + t.name + "` SET `" + softdelete.name + "` = ?" + versionset + " WHERE `" + pk.name + "` = ? AND `" + lock.name + "` = ? AND `" + softdelete.name + "` IS NULL\"\n")
+					buf.WriteString("\tr, err := " + handle + ".ExecContext(ctx, q, " + softdelete.GenerateSQL(sqlprefix) + ", " + pk.GenerateSQL(sqlprefix) + ", " + sqlprefix + CamelCase(lock.name) + ")\n")
+					buf.WriteString("\tvar rows int64\n")
+					buf.WriteString("\tif err == nil {\n")
+					buf.WriteString("\t\trows, err = r.RowsAffected()\n")
+					buf.WriteString("\t\tif err == nil && rows == 0 {\n")
+					buf.WriteString("\t\t\terr = orm.ErrConflict\n")
+					buf.WriteString("\t\t}\n")
+					buf.WriteString("\t}\n")
+					buf.WriteString("\tif err != nil {\n")
+					buf.WriteString("\t\t" + field + " = _" + softdelete.name + "\n")
+					buf.WriteString("\t\treturn false, orm.WrapError(err)\n")
+					buf.WriteString("\t}\n")
+					buf.WriteString(versionincrement)
+					buf.WriteString(generateHook("AfterDelete", handle, prefix, "true", "\t"))
+					buf.WriteString("\treturn true, nil\n")
+				} else {
+					buf.WriteString("\tq := \"DELETE FROM `" + t.name + "` WHERE `" + pk.name + "` = ? AND `" + lock.name + "` = ?\"\n")
+					buf.WriteString("\tr, err := " + handle + ".ExecContext(ctx, q, " + pk.GenerateSQL(sqlprefix) + ", " + sqlprefix + CamelCase(lock.name) + ")\n")
+					buf.WriteString("\tif err != nil {\n")
+					buf.WriteString("\t\treturn false, orm.WrapError(err)\n")
+					buf.WriteString("\t}\n")
+					buf.WriteString("\trows, err := r.RowsAffected()\n")
+					buf.WriteString("\tif err != nil {\n")
+					buf.WriteString("\t\treturn false, err\n")
+					buf.WriteString("\t}\n")
+					buf.WriteString("\tif rows == 0 {\n")
+					buf.WriteString("\t\treturn false, orm.ErrConflict\n")
+					buf.WriteString("\t}\n")
+					buf.WriteString(generateAfterHardDelete(handle))
+				}
+				buf.WriteString("}\n")
+				buf.WriteString("\n")
 			}
+
+			// UPDATE with optimistic concurrency
+			generateUpdateOptimistic("DBUpdateOptimistic", "ctx context.Context, db orm.Executor", "db", "")
+
+			// UPDATE with optimistic concurrency and Tx
+			generateMethodShim("DBUpdateOptimistic", "will update the "+n+" record in the database within an existing transaction only if it hasn't changed since it was read", "", "", "(sql.Result, error)")
+
+			// DELETE with optimistic concurrency
+			generateDeleteOptimistic("DBDeleteOptimistic", "ctx context.Context, db orm.Executor", "db", "")
+
+			// DELETE with optimistic concurrency and Tx
+			generateMethodShim("DBDeleteOptimistic", "will delete the "+n+" record within an existing transaction only if it hasn't changed since it was read", "", "", "(bool, error)")
+		}
+
+		// lookups by primary key exclude the soft deleted records unless a scope is passed
+		var softscope, softclause string
+		if softdelete != nil {
+			softscope = ", scope ...orm.SoftDeleteDef"
+			softclause = "\" + orm.SoftDeleteClause(\"" + softdelete.name + "\", scope...)"
 		}
-		buf.WriteString(" WHERE `" + pk.name + "` = ?\"\n")
-		buf.WriteString("\treturn tx.ExecContext(ctx, q,\n")
+
+		// columns which can be changed by a partial update in the order they are compared
+		updatable := make([]*column, 0)
 		for _, column := range t.columns {
-			if column.primarykey == false {
-				buf.WriteString("\t\t" + column.GenerateSQL(sqlprefix) + ",\n")
+			if column.primarykey == false && column.IsChecksum() == false && column != version {
+				updatable = append(updatable, column)
 			}
 		}
-		buf.WriteString("\t\t" + pk.GenerateSQL(sqlprefix) + ",\n")
-		buf.WriteString("\t)\n")
-		buf.WriteString("}\n")
-		buf.WriteString("\n")
-
-		// DELETE
-		buf.WriteString("// DBDelete will delete the " + n + " record in the database\n")
-		buf.WriteString(t.GenerateFuncPrefix(prefix, n, "DBDelete", "ctx context.Context, db *sql.DB", "(bool, error)"))
-		buf.WriteString("\tq := \"DELETE FROM `" + t.name + "` WHERE `" + pk.name + "` = ?\"\n")
-		buf.WriteString("\tr, err := db.ExecContext(ctx, q, " + pk.GenerateSQL(sqlprefix) + ")\n")
-		buf.WriteString("\tif err != nil && err != sql.ErrNoRows {\n")
-		buf.WriteString("\t\treturn false, err\n")
-		buf.WriteString("\t}\n")
-		buf.WriteString("\t" + prefix + "." + CamelCase(pk.name) + " = " + pk.GenerateNullValue() + "\n")
-		buf.WriteString("\trows, err := r.RowsAffected()\n")
-		buf.WriteString("\tif err != nil {\n")
-		buf.WriteString("\t\treturn false, err\n")
-		buf.WriteString("\t}\n")
-		buf.WriteString("\treturn rows > 0, nil\n")
-		buf.WriteString("}\n")
-		buf.WriteString("\n")
+		t.goimports.Add("strings")
 
-		// DELETE Tx
-		buf.WriteString("// DBDeleteTx will delete the " + n + " record in the database within an existing transaction\n")
-		buf.WriteString(t.GenerateFuncPrefix(prefix, n, "DBDeleteTx", "ctx context.Context, tx *sql.Tx", "(bool, error)"))
-		buf.WriteString("\tq := \"DELETE FROM `" + t.name + "` WHERE `" + pk.name + "` = ?\"\n")
-		buf.WriteString("\tr, err := tx.ExecContext(ctx, q, " + pk.GenerateSQL(sqlprefix) + ")\n")
-		buf.WriteString("\tif err != nil && err != sql.ErrNoRows {\n")
-		buf.WriteString("\t\treturn false, err\n")
+		// build the SQL for a partial update which is shared by the db and tx variants
+		fieldsComment := versionComment
+		if checksum != nil {
+			fieldsComment += ". the " + checksum.name + " column is cleared so the record is dirty until it's fully updated"
+		}
+		if updatedat != nil {
+			buf.WriteString("// dbUpdateFields returns the UPDATE statement and parameters for only the fields passed and sets the " + updatedat.name + " column unless it was passed" + fieldsComment + "\n")
+		} else {
+			buf.WriteString("// dbUpdateFields returns the UPDATE statement and parameters for only the fields passed" + fieldsComment + "\n")
+		}
+		buf.WriteString(t.GenerateFuncPrefix(prefix, n, "dbUpdateFields", "fields []"+field, "(string, []interface{}, error)"))
+		buf.WriteString("\tsets := make([]string, 0)\n")
+		buf.WriteString("\tparams := make([]interface{}, 0)\n")
+		if updatedat != nil {
+			buf.WriteString("\t_" + updatedat.name + " := false\n")
+		}
+		buf.WriteString("\tfor _, field := range fields {\n")
+		buf.WriteString("\t\tswitch field {\n")
+		for _, column := range updatable {
+			buf.WriteString("\t\tcase " + field + CamelCase(column.name) + ":\n")
+			buf.WriteString("\t\t\t{\n")
+			if column.IsTypedJSON() {
+				buf.WriteString("\t\t\t\t_" + column.name + ", err := orm.ToSQLJSON(\"" + column.name + "\", " + sqlprefix + CamelCase(column.name) + ")\n")
+				buf.WriteString("\t\t\t\tif err != nil {\n")
+				buf.WriteString("\t\t\t\t\treturn \"\", nil, err\n")
+				buf.WriteString("\t\t\t\t}\n")
+			}
+			buf.WriteString("\t\t\t\tsets = append(sets, \"`" + column.name + "` = " + column.GenerateSQLPlaceholder() + "\")\n")
+			buf.WriteString("\t\t\t\tparams = append(params, " + column.GenerateSQL(sqlprefix) + ")\n")
+			if column == updatedat {
+				buf.WriteString("\t\t\t\t_" + updatedat.name + " = true\n")
+			}
+			buf.WriteString("\t\t\t}\n")
+		}
+		buf.WriteString("\t\tcase " + field + CamelCase(pk.name) + ":\n")
+		buf.WriteString("\t\t\t{\n")
+		buf.WriteString("\t\t\t\treturn \"\", nil, &orm.FieldError{Table: \"" + t.name + "\", Field: string(field), Reason: \"is the primary key and can't be updated\"}\n")
+		buf.WriteString("\t\t\t}\n")
+		if checksum != nil {
+			buf.WriteString("\t\tcase " + field + CamelCase(checksum.name) + ":\n")
+			buf.WriteString("\t\t\t{\n")
+			buf.WriteString("\t\t\t\t// always cleared below\n")
+			buf.WriteString("\t\t\t}\n")
+		}
+		if version != nil {
+			buf.WriteString("\t\tcase " + field + CamelCase(version.name) + ":\n")
+			buf.WriteString("\t\t\t{\n")
+			buf.WriteString("\t\t\t\t// always incremented below\n")
+			buf.WriteString("\t\t\t}\n")
+		}
+		buf.WriteString("\t\tdefault:\n")
+		buf.WriteString("\t\t\t{\n")
+		buf.WriteString("\t\t\t\treturn \"\", nil, &orm.FieldError{Table: \"" + t.name + "\", Field: string(field), Reason: \"is not a column\"}\n")
+		buf.WriteString("\t\t\t}\n")
+		buf.WriteString("\t\t}\n")
 		buf.WriteString("\t}\n")
-		buf.WriteString("\t" + prefix + "." + CamelCase(pk.name) + " = " + pk.GenerateNullValue() + "\n")
-		buf.WriteString("\trows, err := r.RowsAffected()\n")
-		buf.WriteString("\tif err != nil {\n")
-		buf.WriteString("\t\treturn false, err\n")
+		buf.WriteString("\tif len(sets) == 0 {\n")
+		buf.WriteString("\t\treturn \"\", nil, nil\n")
 		buf.WriteString("\t}\n")
-		buf.WriteString("\treturn rows > 0, nil\n")
+		if updatedat != nil {
+			// the updated timestamp is set unless it was one of the fields
+			buf.WriteString("\tif _" + updatedat.name + " == false {\n")
+			buf.WriteString("\t\t" + sqlprefix + CamelCase(updatedat.name) + " = orm.ToTimestampNow()\n")
+			buf.WriteString("\t\tsets = append(sets, \"`" + updatedat.name + "` = ?\")\n")
+			buf.WriteString("\t\tparams = append(params, " + updatedat.GenerateSQL(sqlprefix) + ")\n")
+			buf.WriteString("\t}\n")
+		}
+		if version != nil {
+			buf.WriteString("\tsets = append(sets, \"`" + version.name + "` = `" + version.name + "` + 1\")\n")
+		}
+		if checksum != nil {
+			// the other columns in the database may not match the record so the checksum can't be calculated and is cleared instead
+			buf.WriteString("\tsets = append(sets, \"`" + checksum.name + "` = ''\")\n")
+		}
+		buf.WriteString("\tparams = append(params, " + pk.GenerateSQL(sqlprefix) + ")\n")
+		buf.WriteString("\treturn \"UPDATE `" + t.name + "` SET \" + strings.Join(sets, \", \") + \" WHERE `" + pk.name + "` = ?\", params, nil\n")
 		buf.WriteString("}\n")
 		buf.WriteString("\n")
 
-		// FIND ONE
-		buf.WriteString("// DBFindOne finds a " + n + " for the primary key and populates the record with the results\n")
-		buf.WriteString(t.GenerateFuncPrefix(prefix, n, "DBFindOne", "ctx context.Context, db *sql.DB, "+pk.name+" "+pk.GenerateVariableType(), "(bool, error)"))
-		buf.WriteString("\tq := \"SELECT ")
-		for i, column := range t.columns {
-			buf.WriteString(column.GenerateSQLSelect())
-			if i+1 < colcount {
-				buf.WriteString(",")
+		generateUpdateFields := func(name string, params string, handle string, comment string) {
+			buf.WriteString("// " + name + " will update only the fields passed of the " + n + " record in the database" + comment + ". it returns a nil result if there are no fields to update" + fieldsComment + "\n")
+			buf.WriteString(t.GenerateFuncPrefix(prefix, n, name, params+", fields ..."+field, "(sql.Result, error)"))
+			buf.WriteString(generateHook("BeforeUpdate", handle, prefix, "nil", "\t"))
+			buf.WriteString("\tq, params, err := " + prefix + ".dbUpdateFields(fields)\n")
+			buf.WriteString("\tif err != nil || q == \"\" {\n")
+			buf.WriteString("\t\treturn nil, err\n")
+			buf.WriteString("\t}\n")
+			buf.WriteString("\tr, err := " + handle + ".ExecContext(ctx, q, params...)\n")
+			buf.WriteString("\tif err != nil {\n")
+			buf.WriteString("\t\treturn nil, orm.WrapError(err)\n")
+			buf.WriteString("\t}\n")
+			if checksum != nil {
+				buf.WriteString("\t" + sqlprefix + CamelCase(checksum.name) + " = \"\"\n")
 			}
+			buf.WriteString(versionincrement)
+			buf.WriteString(generateHook("AfterUpdate", handle, prefix, "nil", "\t"))
+			buf.WriteString("\treturn r, nil\n")
+			buf.WriteString("}\n")
+			buf.WriteString("\n")
 		}
-		buf.WriteString(" FROM `" + t.name + "` WHERE `" + pk.name + "` = ? LIMIT 1\"\n")
-		buf.WriteString("\trow := db.QueryRowContext(ctx, q, " + pk.name + ")\n")
-		buf.WriteString(generateScan("row", "", "false"))
-		buf.WriteString("\treturn true, nil\n")
-		buf.WriteString("}\n")
-		buf.WriteString("\n")
 
-		// FIND ONE with Tx
-		buf.WriteString("// DBFindOneTx finds a " + n + " for the primary key and populates the record with the results within an existing transaction\n")
-		buf.WriteString(t.GenerateFuncPrefix(prefix, n, "DBFindOneTx", "ctx context.Context, tx *sql.Tx, "+pk.name+" "+pk.GenerateVariableType(), "(bool, error)"))
-		buf.WriteString("\tq := \"SELECT ")
-		for i, column := range t.columns {
-			buf.WriteString(column.GenerateSQLSelect())
-			if i+1 < colcount {
-				buf.WriteString(",")
-			}
+		generateUpdateMask := func(name string, params string, handle string, fieldsname string, comment string) {
+			buf.WriteString("// " + name + " will update the fields in the mask (such as a google.protobuf.FieldMask) of the " + n + " record in the database" + comment + "\n")
+			buf.WriteString(t.GenerateFuncPrefix(prefix, n, name, params+", mask orm.FieldMask", "(sql.Result, error)"))
+			buf.WriteString("\tpaths := mask.GetPaths()\n")
+			buf.WriteString("\tfields := make([]" + field + ", 0, len(paths))\n")
+			buf.WriteString("\tfor _, path := range paths {\n")
+			buf.WriteString("\t\tfields = append(fields, " + field + "(path))\n")
+			buf.WriteString("\t}\n")
+			buf.WriteString("\treturn " + prefix + "." + fieldsname + "(ctx, " + handle + ", fields...)\n")
+			buf.WriteString("}\n")
+			buf.WriteString("\n")
 		}
-		buf.WriteString(" FROM `" + t.name + "` WHERE `" + pk.name + "` = ? LIMIT 1\"\n")
-		buf.WriteString("\trow := tx.QueryRowContext(ctx, q, " + pk.name + ")\n")
-		buf.WriteString(generateScan("row", "", "false"))
-		buf.WriteString("\treturn true, nil\n")
-		buf.WriteString("}\n")
-		buf.WriteString("\n")
 
-		// EXISTS
-		buf.WriteString("// DBExists returns true if the " + n + " record exists in the database\n")
-		buf.WriteString(t.GenerateFuncPrefix(prefix, n, "DBExists", "ctx context.Context, db *sql.DB", "(bool, error)"))
-		buf.WriteString("\tq := \"SELECT " + pk.GenerateSQLSelect() + " from `" + t.name + "` WHERE " + pk.GenerateSQLSelect() + " = ?\"\n")
-		buf.WriteString("\tvar _" + pk.name + " " + pk.GetSQLType() + "\n")
-		buf.WriteString("\terr := db.QueryRowContext(ctx, q, " + prefix + "." + CamelCase(pk.name) + ").Scan(&_" + pk.name + ")\n")
-		buf.WriteString("\tif err != nil && err != sql.ErrNoRows {\n")
-		buf.WriteString("\t\treturn false, err\n")
+		// UPDATE only some fields
+		generateUpdateFields("DBUpdateFields", "ctx context.Context, db orm.Executor", "db", "")
+
+		// UPDATE only some fields with Tx
+		generateMethodShim("DBUpdateFields", "will update only the fields passed of the "+n+" record in the database within an existing transaction", ", fields ..."+field, ", fields...", "(sql.Result, error)")
+
+		// UPDATE the fields in a mask
+		generateUpdateMask("DBUpdateMask", "ctx context.Context, db orm.Executor", "db", "DBUpdateFields", "")
+
+		// UPDATE the fields in a mask with Tx
+		generateMethodShim("DBUpdateMask", "will update the fields in the mask of the "+n+" record in the database within an existing transaction", ", mask orm.FieldMask", ", mask", "(sql.Result, error)")
+
+		// snapshot of the values used by the tracker to detect changes
+		buf.WriteString("// dbSnapshot returns the string value of each field which can be changed by a partial update\n")
+		buf.WriteString(t.GenerateFuncPrefix(prefix, n, "dbSnapshot", "", "[]string"))
+		buf.WriteString("\treturn []string{\n")
+		for _, column := range updatable {
+			buf.WriteString("\t\torm.ToString(" + sqlprefix + CamelCase(column.name) + "),\n")
+		}
 		buf.WriteString("\t}\n")
-		buf.WriteString("\treturn _" + pk.name + ".Valid, nil\n")
 		buf.WriteString("}\n")
 		buf.WriteString("\n")
 
-		// EXISTS with Tx
-		buf.WriteString("// DBExistsTx returns true if the " + n + " record exists in the database within an existing transaction\n")
-		buf.WriteString(t.GenerateFuncPrefix(prefix, n, "DBExistsTx", "ctx context.Context, tx *sql.Tx", "(bool, error)"))
-		buf.WriteString("\tq := \"SELECT " + pk.GenerateSQLSelect() + " from `" + t.name + "` WHERE " + pk.GenerateSQLSelect() + " = ?\"\n")
-		buf.WriteString("\tvar _" + pk.name + " " + pk.GetSQLType() + "\n")
-		buf.WriteString("\terr := tx.QueryRowContext(ctx, q, " + prefix + "." + CamelCase(pk.name) + ").Scan(&_" + pk.name + ")\n")
-		buf.WriteString("\tif err != nil && err != sql.ErrNoRows {\n")
-		buf.WriteString("\t\treturn false, err\n")
+		tracker := n + "Tracker"
+		buf.WriteString("// " + tracker + " records the values of a " + n + " when it's read so that only the changed fields are updated\n")
+		buf.WriteString("type " + tracker + " struct {\n")
+		buf.WriteString("\tRecord   *" + n + "\n")
+		buf.WriteString("\tsnapshot []string\n")
+		buf.WriteString("}\n\n")
+
+		buf.WriteString("// New" + tracker + " returns a tracker for the record using its current values as the original values\n")
+		buf.WriteString("func New" + tracker + "(record *" + n + ") *" + tracker + " {\n")
+		buf.WriteString("\ttracker := &" + tracker + "{Record: record}\n")
+		buf.WriteString("\ttracker.Reset()\n")
+		buf.WriteString("\treturn tracker\n")
+		buf.WriteString("}\n\n")
+
+		buf.WriteString("// Reset makes the current values of the record the original values\n")
+		buf.WriteString("func (tracker *" + tracker + ") Reset() {\n")
+		buf.WriteString("\ttracker.snapshot = tracker.Record.dbSnapshot()\n")
+		buf.WriteString("}\n\n")
+
+		buf.WriteString("// Changed returns the fields which have changed since the record was read\n")
+		buf.WriteString("func (tracker *" + tracker + ") Changed() []" + field + " {\n")
+		buf.WriteString("\tfields := []" + field + "{\n")
+		for _, column := range updatable {
+			buf.WriteString("\t\t" + field + CamelCase(column.name) + ",\n")
+		}
 		buf.WriteString("\t}\n")
-		buf.WriteString("\treturn _" + pk.name + ".Valid, nil\n")
-		buf.WriteString("}\n")
-		buf.WriteString("\n")
+		buf.WriteString("\tchanged := make([]" + field + ", 0)\n")
+		buf.WriteString("\tfor i, value := range tracker.Record.dbSnapshot() {\n")
+		buf.WriteString("\t\tif tracker.snapshot == nil || value != tracker.snapshot[i] {\n")
+		buf.WriteString("\t\t\tchanged = append(changed, fields[i])\n")
+		buf.WriteString("\t\t}\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\treturn changed\n")
+		buf.WriteString("}\n\n")
 
-		// UPSERT
-		buf.WriteString("// DBUpsert creates or updates a " + n + " record inside a safe transaction\n")
-		buf.WriteString(t.GenerateFuncPrefix(prefix, n, "DBUpsert", "ctx context.Context, db *sql.DB", "(bool, bool, error)"))
-		buf.WriteString("\tq := \"INSERT INTO `" + t.name + "` (")
-		for i, column := range t.columns {
-			buf.WriteString("`" + column.name + "`")
-			if i+1 < colcount {
-				buf.WriteString(",")
-			}
+		generateTrackerFind := func(name string, params string, args string, comment string) {
+			buf.WriteString("// " + name + " calls " + name + " on the record" + comment + " and records the values which were read\n")
+			buf.WriteString("func (tracker *" + tracker + ") " + name + "(" + params + ") (bool, error) {\n")
+			buf.WriteString("\tfound, err := tracker.Record." + name + "(" + args + ")\n")
+			buf.WriteString("\tif found {\n")
+			buf.WriteString("\t\ttracker.Reset()\n")
+			buf.WriteString("\t}\n")
+			buf.WriteString("\treturn found, err\n")
+			buf.WriteString("}\n\n")
 		}
-		buf.WriteString(") VALUES (")
-		for i, column := range t.columns {
-			buf.WriteString(column.GenerateSQLPlaceholder())
-			if i+1 < colcount {
-				buf.WriteString(",")
-			}
+
+		generateTrackerUpdate := func(name string, params string, handle string, fieldsname string, comment string) {
+			buf.WriteString("// " + name + " will update only the fields which have changed since the record was read" + comment + ". it returns a nil result if nothing changed\n")
+			buf.WriteString("func (tracker *" + tracker + ") " + name + "(" + params + ") (sql.Result, error) {\n")
+			buf.WriteString("\tchanged := tracker.Changed()\n")
+			buf.WriteString("\tif len(changed) == 0 {\n")
+			buf.WriteString("\t\treturn nil, nil\n")
+			buf.WriteString("\t}\n")
+			buf.WriteString("\tr, err := tracker.Record." + fieldsname + "(ctx, " + handle + ", changed...)\n")
+			buf.WriteString("\tif err != nil {\n")
+			buf.WriteString("\t\treturn nil, err\n")
+			buf.WriteString("\t}\n")
+			buf.WriteString("\ttracker.Reset()\n")
+			buf.WriteString("\treturn r, nil\n")
+			buf.WriteString("}\n\n")
 		}
-		buf.WriteString(") ON DUPLICATE KEY UPDATE ")
-		for i, column := range t.columns {
-			if column.primarykey == false {
-				buf.WriteString("`" + column.name + "` = VALUES(`" + column.name + "`)")
+
+		pkparam := pk.name + " " + pk.GenerateVariableType() + softscope
+		pkargs := pk.name
+		if softdelete != nil {
+			pkargs += ", scope..."
+		}
+		generateTrackerShim := func(name string, comment string, params string, args string, returnvalue string) {
+			buf.WriteString("// " + name + "Tx " + comment + "\n")
+			buf.WriteString("//\n")
+			buf.WriteString("// Deprecated: use " + name + " which accepts a *sql.Tx as the executor\n")
+			buf.WriteString("func (tracker *" + tracker + ") " + name + "Tx(ctx context.Context, tx *sql.Tx" + params + ") " + returnvalue + " {\n")
+			buf.WriteString("\treturn tracker." + name + "(ctx, tx" + args + ")\n")
+			buf.WriteString("}\n\n")
+		}
+
+		generateTrackerFind("DBFindOne", "ctx context.Context, db orm.Executor, "+pkparam, "ctx, db, "+pkargs, "")
+		generateTrackerShim("DBFindOne", "calls DBFindOne on the record within an existing transaction and records the values which were read", ", "+pkparam, ", "+pkargs, "(bool, error)")
+		generateTrackerFind("DBFind", "ctx context.Context, db orm.Executor, _params ...interface{}", "ctx, db, _params...", "")
+		generateTrackerShim("DBFind", "calls DBFind on the record within an existing transaction and records the values which were read", ", _params ...interface{}", ", _params...", "(bool, error)")
+		generateTrackerUpdate("DBUpdate", "ctx context.Context, db orm.Executor", "db", "DBUpdateFields", "")
+		generateTrackerShim("DBUpdate", "will update only the fields which have changed since the record was read within an existing transaction", "", "", "(sql.Result, error)")
+
+		generateFindOne := func(name string, params string, handle string, comment string) {
+			buf.WriteString("// " + name + " finds a " + n + " for the primary key and populates the record with the results" + comment + "\n")
+			buf.WriteString(t.GenerateFuncPrefix(prefix, n, name, params+", "+pk.name+" "+pk.GenerateVariableType()+softscope, "(bool, error)"))
+			buf.WriteString("\tq := \"SELECT ")
+			for i, column := range t.columns {
+				buf.WriteString(column.GenerateSQLSelect())
 				if i+1 < colcount {
-					buf.WriteString(", ")
+					buf.WriteString(",")
 				}
 			}
-		}
-
-		buf.WriteString("\"\n")
-		buf.WriteString("\tr, err := db.ExecContext(ctx, q,\n")
-		for _, column := range t.columns {
-			if column.IsChecksum() {
-				buf.WriteString("\t\t" + prefix + ".CalculateChecksum()")
+			if softclause != "" {
+				buf.WriteString(" FROM `" + t.name + "` WHERE `" + pk.name + "` = ?" + softclause + " + \" LIMIT 1\"\n")
 			} else {
-				buf.WriteString("\t\t" + column.GenerateSQL(sqlprefix))
+				buf.WriteString(" FROM `" + t.name + "` WHERE `" + pk.name + "` = ? LIMIT 1\"\n")
 			}
-			buf.WriteString(",")
+			buf.WriteString("\trow := " + handle + ".QueryRowContext(ctx, q, " + pk.name + ")\n")
+			buf.WriteString(generateScan("row", "", "false"))
+			buf.WriteString(generateHook("AfterFind", handle, prefix, "true", "\t"))
+			buf.WriteString("\treturn true, nil\n")
+			buf.WriteString("}\n")
 			buf.WriteString("\n")
 		}
-		buf.WriteString("\t)\n")
-		buf.WriteString("\tif err != nil {\n")
-		buf.WriteString("\t\treturn false, false, err\n")
+
+		// FIND ONE
+		generateFindOne("DBFindOne", "ctx context.Context, db orm.Executor", "db", "")
+
+		// FIND ONE with Tx
+		generateMethodShim("DBFindOne", "finds a "+n+" for the primary key and populates the record with the results within an existing transaction", ", "+pkparam, ", "+pkargs, "(bool, error)")
+
+		// EXISTS
+		buf.WriteString("// DBExists returns true if the " + n + " record exists in the database\n")
+		buf.WriteString(t.GenerateFuncPrefix(prefix, n, "DBExists", "ctx context.Context, db orm.Executor"+softscope, "(bool, error)"))
+		if softclause != "" {
+			buf.WriteString("\tq := \"SELECT " + pk.GenerateSQLSelect() + " from `" + t.name + "` WHERE " + pk.GenerateSQLSelect() + " = ?" + softclause + "\n")
+		} else {
+			buf.WriteString("\tq := \"SELECT " + pk.GenerateSQLSelect() + " from `" + t.name + "` WHERE " + pk.GenerateSQLSelect() + " = ?\"\n")
+		}
+		buf.WriteString("\tvar _" + pk.name + " " + pk.GetSQLType() + "\n")
+		buf.WriteString("\terr := db.QueryRowContext(ctx, q, " + prefix + "." + CamelCase(pk.name) + ").Scan(&_" + pk.name + ")\n")
+		buf.WriteString("\tif err != nil && err != sql.ErrNoRows {\n")
+		buf.WriteString("\t\treturn false, orm.WrapError(err)\n")
 		buf.WriteString("\t}\n")
-		buf.WriteString("\tc, _ := r.RowsAffected()\n")
-		buf.WriteString("\treturn c > 0, c == 0, nil\n")
+		buf.WriteString("\treturn _" + pk.name + ".Valid, nil\n")
 		buf.WriteString("}\n")
 		buf.WriteString("\n")
 
-		// UPSERT with Tx
-		buf.WriteString("// DBUpsertTx creates or updates a " + n + " record within an existing transaction\n")
-		buf.WriteString(t.GenerateFuncPrefix(prefix, n, "DBUpsertTx", "ctx context.Context, tx *sql.Tx", "(bool, bool, error)"))
-		buf.WriteString("\tq := \"INSERT INTO `" + t.name + "` (")
-		for i, column := range t.columns {
-			buf.WriteString("`" + column.name + "`")
-			if i+1 < colcount {
-				buf.WriteString(",")
-			}
+		// EXISTS with Tx
+		var softargs string
+		if softdelete != nil {
+			softargs = ", scope..."
 		}
-		buf.WriteString(") VALUES (")
-		for i, column := range t.columns {
-			buf.WriteString(column.GenerateSQLPlaceholder())
-			if i+1 < colcount {
-				buf.WriteString(",")
+		generateMethodShim("DBExists", "returns true if the "+n+" record exists in the database within an existing transaction", softscope, softargs, "(bool, error)")
+
+		generateUpsert := func(name string, params string, handle string, comment string) {
+			var upsertComment string
+			if createdat != nil {
+				upsertComment = ". the " + createdat.name + " column is only set on the record when it's inserted since an existing record keeps its own"
 			}
-		}
-		buf.WriteString(") ON DUPLICATE KEY UPDATE ")
-		for i, column := range t.columns {
-			if column.primarykey == false {
-				buf.WriteString("`" + column.name + "` = VALUES(`" + column.name + "`)")
+			if version != nil {
+				upsertComment += ". the " + version.name + " column of an existing record is incremented in the database but isn't read back"
+			}
+			buf.WriteString("// " + name + " creates or updates a " + n + " record" + comment + upsertComment + "\n")
+			buf.WriteString(t.GenerateFuncPrefix(prefix, n, name, params, "(bool, bool, error)"))
+			buf.WriteString("\tq := \"INSERT INTO `" + t.name + "` (")
+			for i, column := range t.columns {
+				buf.WriteString("`" + column.name + "`")
 				if i+1 < colcount {
-					buf.WriteString(", ")
+					buf.WriteString(",")
 				}
 			}
-		}
-
-		buf.WriteString("\"\n")
-		buf.WriteString("\tr, err := tx.ExecContext(ctx, q,\n")
-		for _, column := range t.columns {
-			if column.IsChecksum() {
-				buf.WriteString("\t\t" + prefix + ".CalculateChecksum()")
-			} else {
-				buf.WriteString("\t\t" + column.GenerateSQL(sqlprefix))
+			buf.WriteString(") VALUES (")
+			for i, column := range t.columns {
+				buf.WriteString(column.GenerateSQLPlaceholder())
+				if i+1 < colcount {
+					buf.WriteString(",")
+				}
+			}
+			sets := append([]string{}, upsertsets...)
+			if lastinsertset != "" {
+				sets = append(sets, lastinsertset)
+			}
+			buf.WriteString(") ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", "))
+			buf.WriteString("\"\n")
+			buf.WriteString(generateHook("BeforeUpsert", handle, prefix, "false, false", "\t"))
+			buf.WriteString(generateCreateKey())
+			buf.WriteString(generateJSONEncode("false, false"))
+			if createdat != nil {
+				buf.WriteString("\tstamped := " + sqlprefix + CamelCase(createdat.name) + " == nil\n")
+			}
+			buf.WriteString(generateTimestamps(true))
+			buf.WriteString("\tr, err := " + handle + ".ExecContext(ctx, q,\n")
+			for _, column := range t.columns {
+				buf.WriteString("\t\t" + generateInsertValue(column) + ",\n")
 			}
-			buf.WriteString(",")
+			buf.WriteString("\t)\n")
+			buf.WriteString("\tif err != nil {\n")
+			if createdat != nil {
+				buf.WriteString("\t\tif stamped {\n")
+				buf.WriteString("\t\t\t" + sqlprefix + CamelCase(createdat.name) + " = nil\n")
+				buf.WriteString("\t\t}\n")
+			}
+			buf.WriteString("\t\treturn false, false, orm.WrapError(err)\n")
+			buf.WriteString("\t}\n")
+			buf.WriteString(generateLastInsertID("false, false"))
+			buf.WriteString("\tc, _ := r.RowsAffected()\n")
+			if createdat != nil {
+				// one row is affected by an insert and two by an update
+				buf.WriteString("\t// an existing record keeps its " + createdat.name + " which isn't read back so it's only set when the record was inserted\n")
+				buf.WriteString("\tif stamped && c != 1 {\n")
+				buf.WriteString("\t\t" + sqlprefix + CamelCase(createdat.name) + " = nil\n")
+				buf.WriteString("\t}\n")
+			}
+			buf.WriteString(generateHook("AfterUpsert", handle, prefix, "c > 0, c == 0", "\t"))
+			buf.WriteString("\treturn c > 0, c == 0, nil\n")
+			buf.WriteString("}\n")
 			buf.WriteString("\n")
 		}
-		buf.WriteString("\t)\n")
-		buf.WriteString("\tif err != nil {\n")
-		buf.WriteString("\t\treturn false, false, err\n")
-		buf.WriteString("\t}\n")
-		buf.WriteString("\tc, _ := r.RowsAffected()\n")
-		buf.WriteString("\treturn c > 0, c == 0, nil\n")
-		buf.WriteString("}\n")
-		buf.WriteString("\n")
 
+		// UPSERT
+		generateUpsert("DBUpsert", "ctx context.Context, db orm.Executor", "db", " with a single INSERT ... ON DUPLICATE KEY UPDATE statement")
+
+		// UPSERT with Tx
+		generateMethodShim("DBUpsert", "creates or updates a "+n+" record within an existing transaction", "", "", "(bool, bool, error)")
+	}
+
+	// reads exclude the soft deleted records unless the params include a scope
+	var softfilter string
+	if softdelete != nil {
+		softfilter = "\t_params = orm.SoftDelete(\"" + softdelete.name + "\", _params)\n"
 	}
 
 	var cstring bytes.Buffer
@@ -512,30 +1095,58 @@ func (t *table) GenerateORM(packageName string, writer io.Writer) error {
 		cstring.WriteString("\tparams = append(params, orm.Column(\"" + column.name + "\"))\n")
 	}
 
-	// FIND
-	buf.WriteString("// DBFind will find a specific " + n + " with a filter\n")
-	buf.WriteString(t.GenerateFuncPrefix(prefix, n, "DBFind", "ctx context.Context, db *sql.DB, _params ...interface{}", "(bool, error)"))
-	buf.WriteString("\tparams := make([]interface{}, 0)\n")
-	buf.WriteString(cstring.String())
-	buf.WriteString(fmt.Sprintf(`	params = append(params, orm.Table("%s"))
-	if len(_params) > 0 {
-		for _, param := range _params {
-			params = append(params, param)
+	// PROJECTION
+	buf.WriteString("// dbProjection returns the columns and scan destinations for only the fields passed and a function which sets the scanned values on the record\n")
+	buf.WriteString(t.GenerateFuncPrefix(prefix, n, "dbProjection", "fields []string", "([]interface{}, []interface{}, func() error, error)"))
+	buf.WriteString("\tcolumns := make([]interface{}, 0, len(fields))\n")
+	buf.WriteString("\tdest := make([]interface{}, 0, len(fields))\n")
+	buf.WriteString("\tsetters := make([]func() error, 0, len(fields))\n")
+	buf.WriteString("\tfor _, field := range fields {\n")
+	buf.WriteString("\t\tswitch " + field + "(field) {\n")
+	for _, column := range t.columns {
+		buf.WriteString("\t\tcase " + field + CamelCase(column.name) + ":\n")
+		buf.WriteString("\t\t\t{\n")
+		buf.WriteString("\t\t\t\tvar _" + column.name + " " + column.GetSQLType() + "\n")
+		buf.WriteString("\t\t\t\tcolumns = append(columns, orm.Column(\"" + column.name + "\"))\n")
+		buf.WriteString("\t\t\t\tdest = append(dest, &_" + column.name + ")\n")
+		buf.WriteString("\t\t\t\tsetters = append(setters, func() error {\n")
+		if column.IsTypedJSON() {
+			buf.WriteString("\t\t\t\t\treturn orm.FromSQLJSON(\"" + column.name + "\", _" + column.name + ", &" + sqlprefix + CamelCase(column.name) + ")\n")
+		} else {
+			buf.WriteString("\t\t\t\t\t" + sqlprefix + CamelCase(column.name) + " = " + column.GenerateSQLSetter("_") + "\n")
+			buf.WriteString("\t\t\t\t\treturn nil\n")
 		}
+		buf.WriteString("\t\t\t\t})\n")
+		buf.WriteString("\t\t\t}\n")
 	}
-`, t.name))
-	buf.WriteString("\tq, p := orm.BuildQuery(params...)\n")
-	buf.WriteString("\trow := db.QueryRowContext(ctx, q, p...)\n")
-	buf.WriteString(generateScan("row", "", "false"))
-	buf.WriteString("\treturn true, nil\n")
+	buf.WriteString("\t\tdefault:\n")
+	buf.WriteString("\t\t\t{\n")
+	buf.WriteString("\t\t\t\treturn nil, nil, nil, &orm.FieldError{Table: \"" + t.name + "\", Field: field, Reason: \"is not a column\"}\n")
+	buf.WriteString("\t\t\t}\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString(`	set := func() error {
+		for _, setter := range setters {
+			if err := setter(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return columns, dest, set, nil
+`)
 	buf.WriteString("}\n")
 	buf.WriteString("\n")
 
-	// FIND with Tx
-	buf.WriteString("// DBFindTx will find a specific " + n + " with a filter\n")
-	buf.WriteString(t.GenerateFuncPrefix(prefix, n, "DBFindTx", "ctx context.Context, tx *sql.Tx, _params ...interface{}", "(bool, error)"))
+	// FIND
+	buf.WriteString("// DBFind will find a specific " + n + " with a filter. a projection is rejected since only Find" + n + "Partial can read one without the record overwriting the columns which weren't read\n")
+	buf.WriteString(t.GenerateFuncPrefix(prefix, n, "DBFind", "ctx context.Context, db orm.Executor, _params ...interface{}", "(bool, error)"))
+	buf.WriteString("\tif err := orm.RejectProjection(_params, \"Find" + n + "Partial\"); err != nil {\n")
+	buf.WriteString("\t\treturn false, err\n")
+	buf.WriteString("\t}\n")
 	buf.WriteString("\tparams := make([]interface{}, 0)\n")
 	buf.WriteString(cstring.String())
+	buf.WriteString(softfilter)
 	buf.WriteString(fmt.Sprintf(`	params = append(params, orm.Table("%s"))
 	if len(_params) > 0 {
 		for _, param := range _params {
@@ -543,16 +1154,24 @@ func (t *table) GenerateORM(packageName string, writer io.Writer) error {
 		}
 	}
 `, t.name))
-	buf.WriteString("\tq, p := orm.BuildQuery(params...)\n")
-	buf.WriteString("\trow := tx.QueryRowContext(ctx, q, p...)\n")
+	buf.WriteString("\tq, p, err := orm.BuildQueryE(params...)\n")
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\treturn false, err\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\trow := db.QueryRowContext(ctx, q, p...)\n")
 	buf.WriteString(generateScan("row", "", "false"))
+	buf.WriteString(generateHook("AfterFind", "db", prefix, "true", "\t"))
 	buf.WriteString("\treturn true, nil\n")
 	buf.WriteString("}\n")
 	buf.WriteString("\n")
 
+	// FIND with Tx
+	generateMethodShim("DBFind", "will find a specific "+n+" with a filter within an existing transaction", ", _params ...interface{}", ", _params...", "(bool, error)")
+
 	// COUNT
 	buf.WriteString("// DBCount will return the total number of " + n + " records with optional filters\n")
-	buf.WriteString(t.GenerateFuncPrefix(prefix, n, "DBCount", "ctx context.Context, db *sql.DB, _params ...interface{}", "(int64, error)"))
+	buf.WriteString(t.GenerateFuncPrefix(prefix, n, "DBCount", "ctx context.Context, db orm.Executor, _params ...interface{}", "(int64, error)"))
+	buf.WriteString(softfilter)
 	buf.WriteString(fmt.Sprintf(`	params := make([]interface{}, 0)
 	params = append(params, orm.CountAlias("*", "count"))
 	params = append(params, orm.Table("%s"))
@@ -562,84 +1181,128 @@ func (t *table) GenerateORM(packageName string, writer io.Writer) error {
 		}
 	}
 `, t.name))
-	buf.WriteString(`	q, p := orm.BuildQuery(params...)
-	var count sql.NullInt64
-	err := db.QueryRowContext(ctx, q, p...).Scan(&count)
-	if err != nil && err != sql.ErrNoRows {
+	buf.WriteString(`	q, p, err := orm.BuildQueryE(params...)
+	if err != nil {
 		return 0, err
 	}
-	return count.Int64, nil
-`)
-	buf.WriteString("}\n")
-	buf.WriteString("\n")
-
-	// COUNT with Tx
-	buf.WriteString("// DBCountTx will return the total number of " + n + " records with optional filters\n")
-	buf.WriteString(t.GenerateFuncPrefix(prefix, n, "DBCountTx", "ctx context.Context, tx *sql.Tx, _params ...interface{}", "(int64, error)"))
-	buf.WriteString(fmt.Sprintf(`	params := make([]interface{}, 0)
-	params = append(params, orm.CountAlias("*", "count"))
-	params = append(params, orm.Table("%s"))
-	if len(_params) > 0 {
-		for _, param := range _params {
-			params = append(params, param)
-		}
-	}
-`, t.name))
-	buf.WriteString(`	q, p := orm.BuildQuery(params...)
 	var count sql.NullInt64
-	err := tx.QueryRowContext(ctx, q, p...).Scan(&count)
+	err = db.QueryRowContext(ctx, q, p...).Scan(&count)
 	if err != nil && err != sql.ErrNoRows {
-		return 0, err
+		return 0, orm.WrapError(err)
 	}
 	return count.Int64, nil
 `)
 	buf.WriteString("}\n")
 	buf.WriteString("\n")
 
+	// COUNT with Tx
+	generateMethodShim("DBCount", "will return the total number of "+n+" records with optional filters within an existing transaction", ", _params ...interface{}", ", _params...", "(int64, error)")
+
 	deleteAll := t.pluralize("DeleteAll" + n)
+	generateDeleteAll := func(name string, comment string) {
+		buf.WriteString("// " + name + " deletes all " + n + " records in the database with optional filters" + comment + "\n")
+		buf.WriteString("func " + name + "(ctx context.Context, db orm.Executor, _params ...interface{}) (error) {\n")
+		buf.WriteString("\tq, p, err := orm.BuildDeleteE(\"" + t.name + "\", _params...)\n")
+		buf.WriteString("\tif err != nil {\n")
+		buf.WriteString("\t\treturn err\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\t_, err = db.ExecContext(ctx, q, p...)\n")
+		buf.WriteString("\treturn orm.WrapError(err)\n")
+		buf.WriteString("}\n")
+		buf.WriteString("\n")
+	}
 
-	// Delete all
-	buf.WriteString("// " + deleteAll + " deletes all " + n + " records in the database with optional filters\n")
-	buf.WriteString("func " + deleteAll + "(ctx context.Context, db *sql.DB, _params ...interface{}) (error) {\n")
-	buf.WriteString("\tparams := make([]interface{}, 0)\n")
-	buf.WriteString(fmt.Sprintf(`	params = append(params, orm.Table("%s"))
-	if len(_params) > 0 {
-		for _, param := range _params {
-			params = append(params, param)
+	if softdelete != nil {
+		// Delete all by setting the soft delete column
+		buf.WriteString("// " + deleteAll + " marks all " + n + " records in the database with optional filters as deleted by setting the " + softdelete.name + " column\n")
+		buf.WriteString("func " + deleteAll + "(ctx context.Context, db orm.Executor, _params ...interface{}) (error) {\n")
+		buf.WriteString(softfilter)
+		if version != nil {
+			buf.WriteString("\tq, p, err := orm.BuildUpdateE(\"" + t.name + "\", []orm.AssignmentDef{orm.Set(\"" + softdelete.name + "\", orm.ToSQLDate(orm.Now().UTC())), orm.Increment(\"" + version.name + "\", 1)}, _params...)\n")
+		} else {
+			buf.WriteString("\tq, p, err := orm.BuildUpdateE(\"" + t.name + "\", []orm.AssignmentDef{orm.Set(\"" + softdelete.name + "\", orm.ToSQLDate(orm.Now().UTC()))}, _params...)\n")
 		}
+		buf.WriteString("\tif err != nil {\n")
+		buf.WriteString("\t\treturn err\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\t_, err = db.ExecContext(ctx, q, p...)\n")
+		buf.WriteString("\treturn orm.WrapError(err)\n")
+		buf.WriteString("}\n")
+		buf.WriteString("\n")
+
+		// Delete all by setting the soft delete column Tx
+		generateFuncShim(deleteAll, "marks all "+n+" records in the database with optional filters as deleted by setting the "+softdelete.name+" column within an existing transaction", ", _params ...interface{}", ", _params...", "error")
+
+		// Delete all the rows
+		hardDeleteAll := t.pluralize("HardDeleteAll" + n)
+		generateDeleteAll(hardDeleteAll, " including the soft deleted records")
+
+		// Delete all the rows Tx
+		generateFuncShim(hardDeleteAll, "deletes all "+n+" records in the database with optional filters within an existing transaction including the soft deleted records", ", _params ...interface{}", ", _params...", "error")
+	} else {
+		// Delete all
+		generateDeleteAll(deleteAll, "")
+
+		// Delete all Tx
+		generateFuncShim(deleteAll, "deletes all "+n+" records in the database with optional filters within an existing transaction", ", _params ...interface{}", ", _params...", "error")
 	}
-`, t.name))
-	buf.WriteString("\tq, p := orm.BuildQuery(params...)\n")
-	buf.WriteString("\t_, err := db.ExecContext(ctx, \"DELETE \"+ q, p...)\n")
-	buf.WriteString("\treturn err\n")
-	buf.WriteString("}\n")
-	buf.WriteString("\n")
 
-	// Delete all Tx
-	buf.WriteString("// " + deleteAll + "Tx deletes all " + n + " records in the database with optional filters\n")
-	buf.WriteString("func " + deleteAll + "Tx(ctx context.Context, tx *sql.Tx, _params ...interface{}) (error) {\n")
-	buf.WriteString("\tparams := make([]interface{}, 0)\n")
-	buf.WriteString(fmt.Sprintf(`	params = append(params, orm.Table("%s"))
-	if len(_params) > 0 {
-		for _, param := range _params {
-			params = append(params, param)
-		}
+	update := t.pluralize("Update" + n)
+
+	// Update many
+	var updateComment string
+	if updatedat != nil {
+		updateComment += ". the " + updatedat.name + " column is set unless it's assigned"
 	}
-`, t.name))
-	buf.WriteString("\tq, p := orm.BuildQuery(params...)\n")
-	buf.WriteString("\t_, err := tx.ExecContext(ctx, \"DELETE \"+ q, p...)\n")
-	buf.WriteString("\treturn err\n")
+	if version != nil {
+		updateComment += ". the " + version.name + " column is incremented unless it's assigned"
+	}
+	if checksum != nil {
+		updateComment += ". the " + checksum.name + " column isn't recalculated"
+	}
+	if softdelete != nil {
+		updateComment += ". soft deleted records aren't updated unless orm.WithDeleted is passed"
+	}
+	buf.WriteString("// " + update + " will update the " + n + " records matching the optional filters with the assignments and return the number of records changed. hooks aren't run" + updateComment + "\n")
+	buf.WriteString("func " + update + "(ctx context.Context, db orm.Executor, set []orm.AssignmentDef, _params ...interface{}) (int64, error) {\n")
+	buf.WriteString("\tif len(set) == 0 {\n")
+	buf.WriteString("\t\treturn 0, nil\n")
+	buf.WriteString("\t}\n")
+	if updatedat != nil {
+		buf.WriteString("\tif orm.HasAssignment(set, \"" + updatedat.name + "\") == false {\n")
+		buf.WriteString("\t\tset = append(set, orm.Set(\"" + updatedat.name + "\", orm.ToSQLDate(orm.Now().UTC())))\n")
+		buf.WriteString("\t}\n")
+	}
+	if version != nil {
+		buf.WriteString("\tif orm.HasAssignment(set, \"" + version.name + "\") == false {\n")
+		buf.WriteString("\t\tset = append(set, orm.Increment(\"" + version.name + "\", 1))\n")
+		buf.WriteString("\t}\n")
+	}
+	buf.WriteString(softfilter)
+	buf.WriteString("\tq, p, err := orm.BuildUpdateE(\"" + t.name + "\", set, _params...)\n")
+	buf.WriteString(`	if err != nil {
+		return 0, err
+	}
+	r, err := db.ExecContext(ctx, q, p...)
+	if err != nil {
+		return 0, orm.WrapError(err)
+	}
+	return r.RowsAffected()
+`)
 	buf.WriteString("}\n")
 	buf.WriteString("\n")
 
 	find := t.pluralize("Find" + n)
-
 	// Find Many
-	buf.WriteString("// " + find + " returns " + n + " records with optional filters\n")
-	buf.WriteString("func " + find + "(ctx context.Context, db *sql.DB, _params ...interface{}) ([]*" + n + ", error) {\n")
+	buf.WriteString("// " + find + " returns " + n + " records with optional filters. a projection is rejected since only " + find + "Partial can read one without the records overwriting the columns which weren't read\n")
+	buf.WriteString("func " + find + "(ctx context.Context, db orm.Executor, _params ...interface{}) ([]*" + n + ", error) {\n")
+	buf.WriteString("\tif err := orm.RejectProjection(_params, \"" + find + "Partial\"); err != nil {\n")
+	buf.WriteString("\t\treturn nil, err\n")
+	buf.WriteString("\t}\n")
 	buf.WriteString("\tresults := make([]*" + n + ",0)\n")
 	buf.WriteString("\tparams := make([]interface{}, 0)\n")
 	buf.WriteString(cstring.String())
+	buf.WriteString(softfilter)
 	buf.WriteString(fmt.Sprintf(`	params = append(params, orm.Table("%s"))
 	if len(_params) > 0 {
 		for _, param := range _params {
@@ -647,10 +1310,13 @@ func (t *table) GenerateORM(packageName string, writer io.Writer) error {
 		}
 	}
 `, t.name))
-	buf.WriteString("\tq, p := orm.BuildQuery(params...)\n")
+	buf.WriteString("\tq, p, err := orm.BuildQueryE(params...)\n")
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\treturn nil, err\n")
+	buf.WriteString("\t}\n")
 	buf.WriteString("\trows, err := db.QueryContext(ctx, q, p...)\n")
 	buf.WriteString("\tif err != nil && err != sql.ErrNoRows {\n")
-	buf.WriteString("\t\treturn nil, err\n")
+	buf.WriteString("\t\treturn nil, orm.WrapError(err)\n")
 	buf.WriteString("\t}\n")
 	buf.WriteString("\tdefer rows.Close()\n")
 	buf.WriteString("\tfor rows.Next() {\n")
@@ -658,44 +1324,174 @@ func (t *table) GenerateORM(packageName string, writer io.Writer) error {
 	buf.WriteString(generateScan("rows", "\t", "nil"))
 	buf.WriteString("\t\tresults = append(results, " + prefix + ")\n")
 	buf.WriteString("\t}\n")
+	buf.WriteString("\t// the rows are closed before the hooks so that they can use the same connection\n")
+	buf.WriteString("\trows.Close()\n")
+	buf.WriteString("\tfor _, " + prefix + " := range results {\n")
+	buf.WriteString(generateHook("AfterFind", "db", prefix, "nil", "\t\t"))
+	buf.WriteString("\t}\n")
 	buf.WriteString("\treturn results, nil\n")
 	buf.WriteString("}\n")
 	buf.WriteString("\n")
 
 	// Find Many Tx
-	buf.WriteString("// " + find + "Tx returns " + n + " records with optional filters\n")
-	buf.WriteString("func " + find + "Tx(ctx context.Context, tx *sql.Tx, _params ...interface{}) ([]*" + n + ", error) {\n")
-	buf.WriteString("\tresults := make([]*" + n + ",0)\n")
-	buf.WriteString("\tparams := make([]interface{}, 0)\n")
-	buf.WriteString(cstring.String())
-	buf.WriteString(fmt.Sprintf(`	params = append(params, orm.Table("%s"))
-	if len(_params) > 0 {
-		for _, param := range _params {
-			params = append(params, param)
+	generateFuncShim(find, "returns "+n+" records with optional filters within an existing transaction", ", _params ...interface{}", ", _params...", "([]*"+n+", error)")
+
+	partial := "Partial" + n
+	partialrecord := prefix + "PartialRecord"
+
+	// PARTIAL record read with a projection. the record is a type without the methods of the table so that only the methods of the partial can update it
+	buf.WriteString("// " + partialrecord + " has the fields of a " + n + " without its methods\n")
+	buf.WriteString("type " + partialrecord + " " + n + "\n\n")
+	buf.WriteString("// " + partial + " is a " + n + " read with a projection and the fields which were read. only those fields can be updated so that the fields which weren't read are never overwritten\n")
+	buf.WriteString("type " + partial + " struct {\n")
+	buf.WriteString("\t*" + partialrecord + "\n")
+	buf.WriteString("\tFields []" + field + "\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("// Loaded returns true if the field was read\n")
+	buf.WriteString("func (partial *" + partial + ") Loaded(field " + field + ") bool {\n")
+	buf.WriteString(`	for _, f := range partial.Fields {
+		if f == field {
+			return true
 		}
 	}
-`, t.name))
-	buf.WriteString("\tq, p := orm.BuildQuery(params...)\n")
-	buf.WriteString("\trows, err := tx.QueryContext(ctx, q, p...)\n")
-	buf.WriteString("\tif err != nil && err != sql.ErrNoRows {\n")
-	buf.WriteString("\t\treturn nil, err\n")
+	return false
+}
+
+`)
+
+	// the fields read by a projection always include the primary key so that the record can be updated
+	projected := "only.Names"
+	if pk != nil {
+		projected = "only.With(\"" + pk.name + "\")"
+
+		buf.WriteString("// DBUpdateFields will update only the fields passed of the record. a FieldError is returned if a field wasn't read\n")
+		buf.WriteString("func (partial *" + partial + ") DBUpdateFields(ctx context.Context, db orm.Executor, fields ..." + field + ") (sql.Result, error) {\n")
+		buf.WriteString("\tfor _, field := range fields {\n")
+		buf.WriteString("\t\tif partial.Loaded(field) == false {\n")
+		buf.WriteString("\t\t\treturn nil, &orm.FieldError{Table: \"" + t.name + "\", Field: string(field), Reason: \"wasn't read by the projection\"}\n")
+		buf.WriteString("\t\t}\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\treturn (*" + n + ")(partial." + partialrecord + ").DBUpdateFields(ctx, db, fields...)\n")
+		buf.WriteString("}\n\n")
+
+		buf.WriteString("// DBUpdate will update every field which was read except the primary key\n")
+		buf.WriteString("func (partial *" + partial + ") DBUpdate(ctx context.Context, db orm.Executor) (sql.Result, error) {\n")
+		buf.WriteString("\tfields := make([]" + field + ", 0, len(partial.Fields))\n")
+		buf.WriteString("\tfor _, field := range partial.Fields {\n")
+		buf.WriteString("\t\tif field != " + field + CamelCase(pk.name) + " {\n")
+		buf.WriteString("\t\t\tfields = append(fields, field)\n")
+		buf.WriteString("\t\t}\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\treturn (*" + n + ")(partial." + partialrecord + ").DBUpdateFields(ctx, db, fields...)\n")
+		buf.WriteString("}\n\n")
+	}
+
+	// the query and fields of a projection which are shared by the find functions
+	buf.WriteString("// " + prefix + "PartialQuery returns the query for the projection and the fields it reads\n")
+	buf.WriteString("func " + prefix + "PartialQuery(only orm.ProjectionDef, _params []interface{}) (string, []interface{}, []string, error) {\n")
+	buf.WriteString("\tfields := " + projected + "\n")
+	buf.WriteString("\tcolumns, _, _, err := (&" + n + "{}).dbProjection(fields)\n")
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\treturn \"\", nil, nil, err\n")
 	buf.WriteString("\t}\n")
-	buf.WriteString("\tdefer rows.Close()\n")
+	buf.WriteString("\tparams := columns\n")
+	buf.WriteString(softfilter)
+	buf.WriteString("\tparams = append(params, orm.Table(\"" + t.name + "\"))\n")
+	buf.WriteString("\tparams = append(params, _params...)\n")
+	buf.WriteString("\tq, p, err := orm.BuildQueryE(params...)\n")
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\treturn \"\", nil, nil, err\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\treturn q, p, fields, nil\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("// " + prefix + "PartialScan reads a row into a new " + partial + " with the fields\n")
+	buf.WriteString("func " + prefix + "PartialScan(row orm.RowScanner, fields []string) (*" + partial + ", error) {\n")
+	buf.WriteString("\t" + prefix + " := &" + n + "{}\n")
+	buf.WriteString("\t_, dest, set, err := " + prefix + ".dbProjection(fields)\n")
+	buf.WriteString(`	if err != nil {
+		return nil, err
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if err := set(); err != nil {
+		return nil, err
+	}
+`)
+	buf.WriteString("\tpartial := &" + partial + "{" + partialrecord + ": (*" + partialrecord + ")(" + prefix + "), Fields: make([]" + field + ", len(fields))}\n")
+	buf.WriteString("\tfor i, f := range fields {\n")
+	buf.WriteString("\t\tpartial.Fields[i] = " + field + "(f)\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\treturn partial, nil\n")
+	buf.WriteString("}\n\n")
+
+	findPartial := find + "Partial"
+	buf.WriteString("// " + findPartial + " returns " + n + " records with optional filters reading only the fields in the projection")
+	if pk != nil {
+		buf.WriteString(" and the primary key")
+	}
+	buf.WriteString("\n")
+	buf.WriteString("func " + findPartial + "(ctx context.Context, db orm.Executor, only orm.ProjectionDef, _params ...interface{}) ([]*" + partial + ", error) {\n")
+	buf.WriteString("\tq, p, fields, err := " + prefix + "PartialQuery(only, _params)\n")
+	buf.WriteString(`	if err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, q, p...)
+	if err != nil {
+		return nil, orm.WrapError(err)
+	}
+	defer rows.Close()
+`)
+	buf.WriteString("\tresults := make([]*" + partial + ", 0)\n")
 	buf.WriteString("\tfor rows.Next() {\n")
-	buf.WriteString("\t\t" + prefix + " := &" + n + "{}\n")
-	buf.WriteString(generateScan("rows", "\t", "nil"))
-	buf.WriteString("\t\tresults = append(results, " + prefix + ")\n")
+	buf.WriteString("\t\tpartial, err := " + prefix + "PartialScan(rows, fields)\n")
+	buf.WriteString(`		if err != nil {
+			return nil, orm.WrapError(err)
+		}
+		results = append(results, partial)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, orm.WrapError(err)
+	}
+	// the rows are closed before the hooks so that they can use the same connection
+	rows.Close()
+	for _, partial := range results {
+`)
+	buf.WriteString("\t\t" + prefix + " := (*" + n + ")(partial." + partialrecord + ")\n")
+	buf.WriteString(generateHook("AfterFind", "db", prefix, "nil", "\t\t"))
 	buf.WriteString("\t}\n")
 	buf.WriteString("\treturn results, nil\n")
-	buf.WriteString("}\n")
-	buf.WriteString("\n")
+	buf.WriteString("}\n\n")
+
+	findOnePartial := "Find" + n + "Partial"
+	buf.WriteString("// " + findOnePartial + " returns the first " + n + " record with optional filters reading only the fields in the projection or nil if there isn't one\n")
+	buf.WriteString("func " + findOnePartial + "(ctx context.Context, db orm.Executor, only orm.ProjectionDef, _params ...interface{}) (*" + partial + ", error) {\n")
+	buf.WriteString("\tq, p, fields, err := " + prefix + "PartialQuery(only, _params)\n")
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\treturn nil, err\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tpartial, err := " + prefix + "PartialScan(db.QueryRowContext(ctx, q, p...), fields)\n")
+	buf.WriteString(`	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, orm.WrapError(err)
+	}
+`)
+	buf.WriteString("\t" + prefix + " := (*" + n + ")(partial." + partialrecord + ")\n")
+	buf.WriteString(generateHook("AfterFind", "db", prefix, "nil", "\t"))
+	buf.WriteString("\treturn partial, nil\n")
+	buf.WriteString("}\n\n")
 
 	count := t.pluralize("Count" + n)
 
 	// Count
 	buf.WriteString("// " + count + " returns the number of " + t.pluralize(n) + " with optional filters\n")
-	buf.WriteString("func " + count + "(ctx context.Context, tx *sql.Tx, _params ...interface{}) (int, error) {\n")
+	buf.WriteString("func " + count + "(ctx context.Context, db orm.Executor, _params ...interface{}) (int, error) {\n")
 	buf.WriteString("\tparams := make([]interface{}, 0)\n")
+	buf.WriteString(softfilter)
 	buf.WriteString(fmt.Sprintf(`	params = append(params, orm.Count("*"), orm.Table("%s"))
 	if len(_params) > 0 {
 		for _, param := range _params {
@@ -703,39 +1499,448 @@ func (t *table) GenerateORM(packageName string, writer io.Writer) error {
 		}
 	}
 `, t.name))
-	buf.WriteString("\tq, p := orm.BuildQuery(params...)\n")
+	buf.WriteString("\tq, p, err := orm.BuildQueryE(params...)\n")
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\treturn 0, err\n")
+	buf.WriteString("\t}\n")
 	buf.WriteString("\tvar c int\n")
-	buf.WriteString("\terr := tx.QueryRowContext(ctx, q, p...).Scan(&c)\n")
+	buf.WriteString("\terr = db.QueryRowContext(ctx, q, p...).Scan(&c)\n")
 	buf.WriteString("\tif err != nil && err != sql.ErrNoRows {\n")
-	buf.WriteString("\t\treturn 0, err\n")
+	buf.WriteString("\t\treturn 0, orm.WrapError(err)\n")
 	buf.WriteString("\t}\n")
 	buf.WriteString("\treturn c, nil\n")
 	buf.WriteString("}\n")
 	buf.WriteString("\n")
 
 	// Count Tx
-	buf.WriteString("// " + count + "Tx returns the number of " + t.pluralize(n) + " with optional filters\n")
-	buf.WriteString("func " + count + "Tx(ctx context.Context, tx *sql.Tx, _params ...interface{}) (int, error) {\n")
+	generateFuncShim(count, "returns the number of "+t.pluralize(n)+" with optional filters within an existing transaction", ", _params ...interface{}", ", _params...", "(int, error)")
+
+	// columns which can be in the key of an aggregate group
+	groupable := make([]*column, 0)
+	for _, column := range t.columns {
+		if column.GenerateGroupType() != "" {
+			groupable = append(groupable, column)
+			if column.IsTimestamp() {
+				t.goimports.Add("time")
+			}
+		}
+	}
+
+	group := n + "Group"
+	buf.WriteString("// " + group + " is the key of a group of " + n + " records in the results of an aggregate. only the fields of the grouped columns are set and NULL is grouped with the zero value\n")
+	buf.WriteString("type " + group + " struct {\n")
+	for _, column := range groupable {
+		buf.WriteString("\t" + CamelCase(column.name) + " " + column.GenerateGroupType() + "\n")
+	}
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("// dbScanner returns the scan destinations for the grouped fields and a function which sets the scanned values on the group\n")
+	buf.WriteString("func (group *" + group + ") dbScanner(fields []" + field + ") ([]interface{}, func()) {\n")
+	buf.WriteString("\tdest := make([]interface{}, 0, len(fields))\n")
+	buf.WriteString("\tsetters := make([]func(), 0, len(fields))\n")
+	buf.WriteString("\tfor _, field := range fields {\n")
+	buf.WriteString("\t\tswitch field {\n")
+	for _, column := range groupable {
+		buf.WriteString("\t\tcase " + field + CamelCase(column.name) + ":\n")
+		buf.WriteString("\t\t\t{\n")
+		buf.WriteString("\t\t\t\tvar _" + column.name + " " + column.GetSQLType() + "\n")
+		buf.WriteString("\t\t\t\tdest = append(dest, &_" + column.name + ")\n")
+		buf.WriteString("\t\t\t\tsetters = append(setters, func() {\n")
+		buf.WriteString("\t\t\t\t\tgroup." + CamelCase(column.name) + " = " + column.GenerateGroupSetter("_") + "\n")
+		buf.WriteString("\t\t\t\t})\n")
+		buf.WriteString("\t\t\t}\n")
+	}
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString(`	return dest, func() {
+		for _, setter := range setters {
+			setter()
+		}
+	}
+`)
+	buf.WriteString("}\n\n")
+
+	aggregate := "aggregate" + t.pluralize(n)
+
+	// AGGREGATE
+	buf.WriteString("// " + aggregate + " calls add with the group and the scanned value of the aggregate column for each group of " + n + " records\n")
+	buf.WriteString("func " + aggregate + "(ctx context.Context, db orm.Executor, column orm.ColumnDef, groupBy []" + field + ", _params []interface{}, value func() interface{}, add func(" + group + ", interface{})) error {\n")
 	buf.WriteString("\tparams := make([]interface{}, 0)\n")
-	buf.WriteString(fmt.Sprintf(`	params = append(params, orm.Count("*"), orm.Table("%s"))
+	buf.WriteString("\tfor _, field := range groupBy {\n")
+	buf.WriteString("\t\tswitch field {\n")
+	cases := make([]string, 0)
+	for _, column := range groupable {
+		cases = append(cases, field+CamelCase(column.name))
+	}
+	if len(cases) > 0 {
+		buf.WriteString("\t\tcase " + strings.Join(cases, ", ") + ":\n")
+		buf.WriteString("\t\t\t{\n")
+		buf.WriteString("\t\t\t\tparams = append(params, orm.Column(string(field)), orm.GroupBy(string(field)))\n")
+		buf.WriteString("\t\t\t}\n")
+	}
+	buf.WriteString("\t\tdefault:\n")
+	buf.WriteString("\t\t\t{\n")
+	buf.WriteString("\t\t\t\treturn &orm.FieldError{Table: \"" + t.name + "\", Field: string(field), Reason: \"can't be grouped\"}\n")
+	buf.WriteString("\t\t\t}\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tparams = append(params, column)\n")
+	buf.WriteString(softfilter)
+	buf.WriteString("\tparams = append(params, orm.Table(\"" + t.name + "\"))\n")
+	buf.WriteString("\tparams = append(params, _params...)\n")
+	buf.WriteString(`	q, p, err := orm.BuildQueryE(params...)
+	if err != nil {
+		return err
+	}
+	rows, err := db.QueryContext(ctx, q, p...)
+	if err != nil {
+		return orm.WrapError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+`)
+	buf.WriteString("\t\tvar group " + group + "\n")
+	buf.WriteString(`		dest, set := group.dbScanner(groupBy)
+		v := value()
+		if err := rows.Scan(append(dest, v)...); err != nil {
+			return orm.WrapError(err)
+		}
+		set()
+		add(group, v)
+	}
+	return orm.WrapError(rows.Err())
+`)
+	buf.WriteString("}\n\n")
+
+	// merge is the statement which adds a value to the results. groups which differ only by NULL and the zero value are merged
+	generateAggregate := func(name string, comment string, column string, valuetype string, sqltype string, sqlfield string, merge string) {
+		result := "map[" + group + "]" + valuetype
+		buf.WriteString("// " + name + " returns " + comment + " of the " + n + " records matching the optional filters by the group of the fields passed. a single zero group is returned if there are no fields\n")
+		buf.WriteString("func " + name + "(ctx context.Context, db orm.Executor, groupBy []" + field + ", _params ...interface{}) (" + result + ", error) {\n")
+		buf.WriteString("\tresults := make(" + result + ")\n")
+		buf.WriteString("\terr := " + aggregate + "(ctx, db, " + column + ", groupBy, _params, func() interface{} { return &" + sqltype + "{} }, func(group " + group + ", v interface{}) {\n")
+		buf.WriteString("\t\tvalue := v.(*" + sqltype + ")." + sqlfield + "\n")
+		buf.WriteString(merge)
+		buf.WriteString("\t})\n")
+		buf.WriteString("\tif err != nil {\n")
+		buf.WriteString("\t\treturn nil, err\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\treturn results, nil\n")
+		buf.WriteString("}\n")
+		buf.WriteString("\n")
+	}
+	add := "\t\tresults[group] += value\n"
+	generateCompare := func(op string) string {
+		return "\t\tif current, ok := results[group]; ok == false || value " + op + " current {\n\t\t\tresults[group] = value\n\t\t}\n"
+	}
+
+	// COUNT grouped
+	generateAggregate(count+"By", "the number", "orm.Count(\"*\")", "int64", "sql.NullInt64", "Int64", add)
+
+	// SUM, MIN and MAX of the numeric columns which aren't identifiers
+	for _, column := range t.columns {
+		if column.primarykey || column.IsVersion() || column.enums != nil || strings.HasSuffix(column.name, "_id") {
+			continue
+		}
+		var valuetype, sqltype, sqlfield string
+		switch column.prototype {
+		case "int32", "int64":
+			{
+				valuetype, sqltype, sqlfield = "int64", "sql.NullInt64", "Int64"
+			}
+		case "float":
+			{
+				valuetype, sqltype, sqlfield = "float64", "sql.NullFloat64", "Float64"
+			}
+		default:
+			{
+				continue
+			}
+		}
+		suffix := n + CamelCase(column.name)
+		generateAggregate("Sum"+suffix, "the sum of the "+column.name+" column", "orm.Sum(\""+column.name+"\")", valuetype, sqltype, sqlfield, add)
+		generateAggregate("Min"+suffix, "the smallest "+column.name+" column", "orm.Min(\""+column.name+"\")", valuetype, sqltype, sqlfield, generateCompare("<"))
+		generateAggregate("Max"+suffix, "the largest "+column.name+" column", "orm.Max(\""+column.name+"\")", valuetype, sqltype, sqlfield, generateCompare(">"))
+	}
+
+	// JOIN
+	buf.WriteString("// As returns the " + n + " columns qualified by the table name or alias in the order expected by DBScanner so that the record can be selected in a join\n")
+	buf.WriteString("func (c " + columnsType + ") As(table string) []interface{} {\n")
+	buf.WriteString("\treturn []interface{}{\n")
+	for _, column := range t.columns {
+		if column.prototype == "orm.Geometry" {
+			buf.WriteString("\t\torm.ColumnDef{Table: table, Name: \"" + column.name + "\", Func: \"ASTEXT\"},\n")
+		} else {
+			buf.WriteString("\t\torm.TableColumn(table, \"" + column.name + "\"),\n")
+		}
+	}
+	buf.WriteString("\t}\n")
+	buf.WriteString("}\n\n")
+	buf.WriteString("// DBScanner returns the destinations for the columns from " + columns + ".As and a function which copies the scanned values into the record. it can be passed to orm.ScanRow with other records to read a join\n")
+	buf.WriteString(t.GenerateFuncPrefix(prefix, n, "DBScanner", "", "([]interface{}, func() error)"))
+	for _, column := range t.columns {
+		buf.WriteString("\tvar _" + column.name + " " + column.GetSQLType() + "\n")
+	}
+	buf.WriteString("\tdest := []interface{}{\n")
+	for _, column := range t.columns {
+		buf.WriteString("\t\t&_" + column.name + ",\n")
+	}
+	buf.WriteString("\t}\n")
+	buf.WriteString("\treturn dest, func() error {\n")
+	for _, column := range t.columns {
+		if column.IsTypedJSON() {
+			buf.WriteString("\t\tif err := orm.FromSQLJSON(\"" + column.name + "\", _" + column.name + ", &" + sqlprefix + CamelCase(column.name) + "); err != nil {\n")
+			buf.WriteString("\t\t\treturn err\n")
+			buf.WriteString("\t\t}\n")
+			continue
+		}
+		buf.WriteString("\t\t" + sqlprefix + CamelCase(column.name) + " = " + column.GenerateSQLSetter("_") + "\n")
+	}
+	buf.WriteString("\t\treturn nil\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("}\n\n")
+
+	// STREAM
+	forEach := "ForEach" + n
+	buf.WriteString("// " + forEach + " calls fn for each " + n + " record with optional filters without loading all the records into memory. fn can return orm.ErrStop to stop early. the AfterFind hook and fn are called while the rows are still being read so they must not use db when it's a *sql.Tx or *sql.Conn since its connection is busy\n")
+	buf.WriteString("func " + forEach + "(ctx context.Context, db orm.Executor, fn func(*" + n + ") error, _params ...interface{}) error {\n")
+	buf.WriteString("\tparams := make([]interface{}, 0)\n")
+	buf.WriteString(cstring.String())
+	buf.WriteString(softfilter)
+	buf.WriteString(fmt.Sprintf(`	params = append(params, orm.Table("%s"))
 	if len(_params) > 0 {
 		for _, param := range _params {
 			params = append(params, param)
 		}
 	}
 `, t.name))
-	buf.WriteString("\tq, p := orm.BuildQuery(params...)\n")
-	buf.WriteString("\tvar c int\n")
-	buf.WriteString("\terr := tx.QueryRowContext(ctx, q, p...).Scan(&c)\n")
-	buf.WriteString("\tif err != nil && err != sql.ErrNoRows {\n")
-	buf.WriteString("\t\treturn 0, err\n")
+	buf.WriteString("\tq, p, err := orm.BuildQueryE(params...)\n")
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\treturn err\n")
 	buf.WriteString("\t}\n")
-	buf.WriteString("\treturn c, nil\n")
-	buf.WriteString("}\n")
-	buf.WriteString("\n")
+	buf.WriteString("\trows, err := db.QueryContext(ctx, q, p...)\n")
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\treturn orm.WrapError(err)\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tdefer rows.Close()\n")
+	buf.WriteString("\t// the scan buffers are reused for every row\n")
+	for _, column := range t.columns {
+		buf.WriteString("\tvar _" + column.name + " " + column.GetSQLType() + "\n")
+	}
+	buf.WriteString("\tfor rows.Next() {\n")
+	buf.WriteString("\t\tif err := ctx.Err(); err != nil {\n")
+	buf.WriteString("\t\t\treturn err\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t\tif err := rows.Scan(\n")
+	for _, column := range t.columns {
+		buf.WriteString("\t\t\t&_" + column.name + ",\n")
+	}
+	buf.WriteString("\t\t); err != nil {\n")
+	buf.WriteString("\t\t\treturn orm.WrapError(err)\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t\t" + prefix + " := &" + n + "{}\n")
+	for _, column := range t.columns {
+		if column.IsTypedJSON() {
+			buf.WriteString("\t\tif err := orm.FromSQLJSON(\"" + column.name + "\", _" + column.name + ", &" + sqlprefix + CamelCase(column.name) + "); err != nil {\n")
+			buf.WriteString("\t\t\treturn err\n")
+			buf.WriteString("\t\t}\n")
+			continue
+		}
+		buf.WriteString("\t\t" + sqlprefix + CamelCase(column.name) + " = " + column.GenerateSQLSetter("_") + "\n")
+	}
+	buf.WriteString("\t\t// the rows are still open so a hook which queries needs a connection other than the one reading them\n")
+	buf.WriteString("\t\tif err := orm.RunAfterFind(ctx, db, " + prefix + "); err != nil {\n")
+	buf.WriteString("\t\t\treturn err\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t\tif err := fn(" + prefix + "); err != nil {\n")
+	buf.WriteString("\t\t\tif err == orm.ErrStop {\n")
+	buf.WriteString("\t\t\t\treturn nil\n")
+	buf.WriteString("\t\t\t}\n")
+	buf.WriteString("\t\t\treturn err\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\treturn orm.WrapError(rows.Err())\n")
+	buf.WriteString("}\n\n")
+	generateFuncShim(forEach, "calls fn for each "+n+" record with optional filters within an existing transaction without loading all the records into memory", ", fn func(*"+n+") error, _params ...interface{}", ", fn, _params...", "error")
+
+	// PAGE
+	if pk != nil {
+		page := t.pluralize("Page" + n)
+		pageParams := strings.ToLower(page[0:1]) + page[1:] + "Params"
+		pageResults := strings.ToLower(page[0:1]) + page[1:] + "Results"
+		// a keyset comparison skips NULLs and an enum is compared by its index and not the value in the cursor
+		keysets := make([]*column, 0)
+		for _, column := range t.columns {
+			if column == pk || (column.nullable == false && column.enums == nil && column.IsJSON() == false && column.prototype != "bytes" && column.prototype != "orm.Geometry") {
+				keysets = append(keysets, column)
+			}
+		}
+		buf.WriteString("// dbCursorValues returns the keyset values of the record for a page ordered by the named column\n")
+		buf.WriteString("func (" + prefix + " *" + n + ") dbCursorValues(name string) []interface{} {\n")
+		buf.WriteString("\tswitch name {\n")
+		for _, column := range keysets {
+			if column == pk {
+				continue
+			}
+			buf.WriteString("\tcase \"" + column.name + "\":\n")
+			buf.WriteString("\t\treturn []interface{}{" + column.GenerateSQL(sqlprefix) + ", " + pk.GenerateSQL(sqlprefix) + "}\n")
+		}
+		buf.WriteString("\t}\n")
+		buf.WriteString("\treturn []interface{}{" + pk.GenerateSQL(sqlprefix) + "}\n")
+		buf.WriteString("}\n\n")
+
+		buf.WriteString("func " + pageParams + "(pageSize int32, cursor string, order orm.OrderDef, _params []interface{}) (*orm.Cursor, []interface{}, error) {\n")
+		buf.WriteString("\tif order.Name == \"\" {\n")
+		buf.WriteString("\t\torder = orm.Ascending(\"" + pk.name + "\")\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\tswitch order.Name {\n")
+		var names []string
+		for _, column := range keysets {
+			names = append(names, "\""+column.name+"\"")
+		}
+		buf.WriteString("\tcase " + strings.Join(names, ", ") + ":\n")
+		buf.WriteString("\tdefault:\n")
+		buf.WriteString("\t\treturn nil, nil, &orm.FieldError{Table: \"" + t.name + "\", Field: order.Name, Reason: \"cannot be used to order a page since it's nullable, an enum or can't be compared\"}\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\tif err := orm.CheckPageParams(_params); err != nil {\n")
+		buf.WriteString("\t\treturn nil, nil, err\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\tc, err := orm.DecodeCursor(cursor, order)\n")
+		buf.WriteString("\tif err != nil {\n")
+		buf.WriteString("\t\treturn nil, nil, err\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\tparams, err := orm.KeysetParams(c, \"" + pk.name + "\", pageSize)\n")
+		buf.WriteString("\tif err != nil {\n")
+		buf.WriteString("\t\treturn nil, nil, err\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\treturn c, append(_params, params...), nil\n")
+		buf.WriteString("}\n\n")
+
+		buf.WriteString("func " + pageResults + "(results []*" + n + ", pageSize int32, c *orm.Cursor) ([]*" + n + ", orm.PageInfo, error) {\n")
+		buf.WriteString("\tmore := len(results) > int(pageSize)\n")
+		buf.WriteString("\tif more {\n")
+		buf.WriteString("\t\tresults = results[0:pageSize]\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\tif len(results) == 0 {\n")
+		buf.WriteString("\t\treturn results, orm.PageInfo{}, nil\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\t// a backward page is selected in reverse order\n")
+		buf.WriteString("\tif c.Backward {\n")
+		buf.WriteString("\t\tfor i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {\n")
+		buf.WriteString("\t\t\tresults[i], results[j] = results[j], results[i]\n")
+		buf.WriteString("\t\t}\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\tinfo, err := orm.NewPageInfo(c, more, results[0].dbCursorValues(c.Order.Name), results[len(results)-1].dbCursorValues(c.Order.Name))\n")
+		buf.WriteString("\tif err != nil {\n")
+		buf.WriteString("\t\treturn nil, orm.PageInfo{}, err\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\treturn results, info, nil\n")
+		buf.WriteString("}\n\n")
+
+		buf.WriteString("// " + page + " returns a page of " + n + " records with optional filters after the cursor ordered by the order column and then the primary key, along with the cursors of the next and previous pages. an empty cursor returns the first page and the params can't have an order or limit\n")
+		buf.WriteString("func " + page + "(ctx context.Context, db orm.Executor, pageSize int32, cursor string, order orm.OrderDef, _params ...interface{}) ([]*" + n + ", orm.PageInfo, error) {\n")
+		buf.WriteString("\tc, params, err := " + pageParams + "(pageSize, cursor, order, _params)\n")
+		buf.WriteString("\tif err != nil {\n")
+		buf.WriteString("\t\treturn nil, orm.PageInfo{}, err\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\tresults, err := " + find + "(ctx, db, params...)\n")
+		buf.WriteString("\tif err != nil {\n")
+		buf.WriteString("\t\treturn nil, orm.PageInfo{}, err\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\treturn " + pageResults + "(results, pageSize, c)\n")
+		buf.WriteString("}\n\n")
+		generateFuncShim(page, "returns a page of "+n+" records with optional filters within an existing transaction after the cursor, along with the cursors of the next and previous pages", ", pageSize int32, cursor string, order orm.OrderDef, _params ...interface{}", ", pageSize, cursor, order, _params...", "([]*"+n+", orm.PageInfo, error)")
+	}
+
+	// BATCH
+	columnnames := make([]string, 0)
+	placeholders := make([]string, 0)
+	for _, column := range t.columns {
+		columnnames = append(columnnames, "`"+column.name+"`")
+		placeholders = append(placeholders, column.GenerateSQLPlaceholder())
+	}
+	generateBatch := func(name string, comment string, before string, after string, upsert bool, suffix string) {
+		buf.WriteString("// " + name + " " + comment + " using multi-row statements which are split by the placeholder limit and max_allowed_packet. the result of each statement is returned. the statements are run in a transaction so that they all fail together unless db is already a *sql.Tx, which the caller has to roll back on an error\n")
+		buf.WriteString("func " + name + "(ctx context.Context, db orm.Executor, records []*" + n + ") ([]orm.BatchResult, error) {\n")
+		buf.WriteString("\trows := make([][]interface{}, 0, len(records))\n")
+		if upsert && createdat != nil {
+			buf.WriteString("\tstamped := make([]*" + n + ", 0)\n")
+		}
+		buf.WriteString("\tfor _, " + prefix + " := range records {\n")
+		buf.WriteString(generateHook(before, "db", prefix, "nil", "\t\t"))
+		if upsert && createdat != nil {
+			buf.WriteString("\t\tif " + sqlprefix + CamelCase(createdat.name) + " == nil {\n")
+			buf.WriteString("\t\t\tstamped = append(stamped, " + prefix + ")\n")
+			buf.WriteString("\t\t}\n")
+		}
+		var setup bytes.Buffer
+		setup.WriteString(generateCreateKey())
+		setup.WriteString(generateTimestamps(true))
+		setup.WriteString(generateJSONEncode("nil"))
+		for _, line := range strings.SplitAfter(setup.String(), "\n") {
+			if line != "" {
+				buf.WriteString("\t" + line)
+			}
+		}
+		buf.WriteString("\t\trows = append(rows, []interface{}{\n")
+		for _, column := range t.columns {
+			buf.WriteString("\t\t\t" + generateInsertValue(column) + ",\n")
+		}
+		buf.WriteString("\t\t})\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\tresults, err := orm.ExecBatch(ctx, db, \"INSERT INTO `" + t.name + "` (" + strings.Join(columnnames, ",") + ") VALUES \", \"(" + strings.Join(placeholders, ",") + ")\", \"" + suffix + "\", rows)\n")
+		if upsert && createdat != nil {
+			buf.WriteString("\t// the rows which were inserted aren't known so the " + createdat.name + " of an existing record isn't overwritten on the record\n")
+			buf.WriteString("\tfor _, " + prefix + " := range stamped {\n")
+			buf.WriteString("\t\t" + sqlprefix + CamelCase(createdat.name) + " = nil\n")
+			buf.WriteString("\t}\n")
+		}
+		buf.WriteString("\tfor _, result := range results {\n")
+		buf.WriteString("\t\tfor _, " + prefix + " := range records[result.Offset : result.Offset+result.Count] {\n")
+		buf.WriteString(generateHook(after, "db", prefix, "results", "\t\t\t"))
+		buf.WriteString("\t\t}\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\treturn results, err\n")
+		buf.WriteString("}\n\n")
+		generateFuncShim(name, comment+" within an existing transaction using multi-row statements", ", records []*"+n, ", records", "([]orm.BatchResult, error)")
+	}
+
+	// INSERT many records
+	createMany := t.pluralize("DBCreateMany" + n)
+	var batchNotes []string
+	if t.idstrategy == "auto" {
+		batchNotes = append(batchNotes, "the auto increment keys aren't read back")
+	}
+	var batchComment string
+	if len(batchNotes) > 0 {
+		batchComment = " (" + strings.Join(batchNotes, " and ") + ")"
+	}
+	generateBatch(createMany, "will create the "+n+" records in the database"+batchComment, "BeforeCreate", "AfterCreate", false, "")
 
-	buf.Flush()
-	return nil
+	if pk != nil {
+		// UPSERT many records
+		upsertMany := t.pluralize("DBUpsertMany" + n)
+		if createdat != nil {
+			batchNotes = append(batchNotes, "the "+createdat.name+" column isn't set on the records since an existing record keeps its own")
+		}
+		if len(batchNotes) > 0 {
+			batchComment = " (" + strings.Join(batchNotes, " and ") + ")"
+		}
+		generateBatch(upsertMany, "will create or update the "+n+" records in the database"+batchComment, "BeforeUpsert", "AfterUpsert", true, "ON DUPLICATE KEY UPDATE "+strings.Join(upsertsets, ", "))
+	}
+
+	out := bufio.NewWriter(writer)
+	out.WriteString("package " + packageName + ";\n\n")
+	out.WriteString("import (\n")
+	out.WriteString("\t\"context\"\n")
+	out.WriteString("\t\"database/sql\"\n")
+	out.WriteString("\t\"github.com/jhaynie/dbgen/pkg/orm\"\n")
+	if len(t.goimports.imports) > 0 {
+		out.WriteString(t.goimports.GoString())
+	}
+	out.WriteString(")\n\n")
+	out.Write(body.Bytes())
+	return out.Flush()
 }
 
 func (t *table) GenerateORMToDir(packageName, schemaDir string) error {
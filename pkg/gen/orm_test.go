@@ -0,0 +1,275 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testColumn struct {
+	name, key, datatype, columntype, def, extra string
+	maxlength                                   int64
+	nullable                                    bool
+}
+
+// test tables which cover the column types and conventions the generator handles specially
+var testTables = []struct {
+	name    string
+	columns []testColumn
+}{
+	{"activity_summary", []testColumn{
+		{name: "id", key: "PRI", datatype: "char", columntype: "char(64)", maxlength: 64},
+		{name: "repo_id", datatype: "int", columntype: "int(11)"},
+		{name: "commits", datatype: "int", columntype: "int(11)", nullable: true},
+		{name: "day", datatype: "date", columntype: "date"},
+		{name: "timezone", datatype: "char", columntype: "char(6)", maxlength: 6},
+	}},
+	{"widget", []testColumn{
+		{name: "id", key: "PRI", datatype: "int", columntype: "int(11)", extra: "auto_increment"},
+		{name: "name", datatype: "varchar", columntype: "varchar(255)", maxlength: 255},
+		{name: "data", datatype: "json", columntype: "json", nullable: true},
+		{name: "raw", datatype: "json", columntype: "json", nullable: true},
+		{name: "status", datatype: "enum", columntype: "enum('open','closed','+1')"},
+		{name: "price", datatype: "float", columntype: "float", nullable: true},
+		{name: "active", datatype: "tinyint", columntype: "tinyint(1)"},
+		{name: "payload", datatype: "blob", columntype: "blob", nullable: true},
+		{name: "location", datatype: "geometry", columntype: "geometry", nullable: true},
+		{name: "checksum", datatype: "char", columntype: "char(64)", maxlength: 64, nullable: true},
+		{name: "version", datatype: "int", columntype: "int(11)", def: "0"},
+		{name: "created_at", datatype: "datetime", columntype: "datetime", nullable: true},
+		{name: "updated_at", datatype: "datetime", columntype: "datetime", nullable: true},
+		{name: "deleted_at", datatype: "datetime", columntype: "datetime", nullable: true},
+	}},
+	{"event_log", []testColumn{
+		{name: "id", key: "PRI", datatype: "binary", columntype: "binary(16)", maxlength: 16},
+		{name: "message", datatype: "text", columntype: "text", nullable: true},
+		{name: "created_at", datatype: "timestamp", columntype: "timestamp", nullable: true},
+	}},
+	{"nopk", []testColumn{
+		{name: "a", datatype: "int", columntype: "int(11)"},
+		{name: "b", datatype: "varchar", columntype: "varchar(10)", maxlength: 10},
+	}},
+}
+
+var testConfig = &Config{
+	Tables: map[string]*TableConfig{
+		"widget": {JSONTypes: map[string]*JSONType{
+			"data": {Message: "google.protobuf.Struct"},
+			"raw":  {GoType: "json.RawMessage", GoImport: "encoding/json"},
+		}},
+		"event_log": {IDStrategy: "uuidv7"},
+	},
+}
+
+// generateTestProtobuf writes the parts of the protoc output for the table which the generated code uses
+func generateTestProtobuf(t *table, schemaDir string) error {
+	var buf bytes.Buffer
+	n := CamelCase(t.name)
+	buf.WriteString("package schema\n\n")
+	buf.WriteString("import (\n")
+	buf.WriteString("\tgoogle_protobuf \"github.com/golang/protobuf/ptypes/timestamp\"\n")
+	buf.WriteString("\tstructpb \"github.com/golang/protobuf/ptypes/struct\"\n")
+	buf.WriteString("\t\"github.com/jhaynie/dbgen/pkg/orm\"\n")
+	buf.WriteString(")\n\n")
+	buf.WriteString("var _ = google_protobuf.Timestamp{}\n")
+	buf.WriteString("var _ = structpb.Struct{}\n")
+	buf.WriteString("var _ = orm.Geometry{}\n\n")
+	for _, c := range t.columns {
+		if c.enums != nil {
+			tn := n + "_" + c.enums.name
+			buf.WriteString("type " + tn + " int32\n\n")
+			for i, e := range c.enums.enums {
+				buf.WriteString(fmt.Sprintf("const %s_%s %s = %d\n", n, e.String(), tn, i))
+			}
+			buf.WriteString("\nvar " + tn + "_value = map[string]int32{\n")
+			for i, e := range c.enums.enums {
+				buf.WriteString(fmt.Sprintf("\t\"%s\": %d,\n", e.String(), i))
+			}
+			buf.WriteString("}\n\n")
+		}
+	}
+	buf.WriteString("type " + n + " struct {\n")
+	for _, c := range t.columns {
+		var gotype string
+		switch c.prototype {
+		case "float":
+			{
+				gotype = "float32"
+			}
+		case "bytes":
+			{
+				gotype = "[]byte"
+			}
+		case "google.protobuf.Timestamp":
+			{
+				gotype = "*google_protobuf.Timestamp"
+			}
+		case "google.protobuf.Struct":
+			{
+				gotype = "*structpb.Struct"
+			}
+		case "orm.Geometry":
+			{
+				gotype = "*orm.Geometry"
+			}
+		default:
+			{
+				if c.enums != nil {
+					gotype = n + "_" + c.enums.name
+				} else {
+					gotype = c.prototype
+				}
+			}
+		}
+		buf.WriteString("\t" + CamelCase(c.name) + " " + gotype + "\n")
+	}
+	buf.WriteString("}\n\n")
+	buf.WriteString("func (m *" + n + ") Reset()         { *m = " + n + "{} }\n")
+	buf.WriteString("func (m *" + n + ") String() string { return \"\" }\n")
+	buf.WriteString("func (*" + n + ") ProtoMessage()    {}\n")
+	return ioutil.WriteFile(filepath.Join(schemaDir, t.name+".pb.go"), buf.Bytes(), 0644)
+}
+
+func TestGenerateORM(t *testing.T) {
+	// generate inside the package so that the vendored imports of the generated code resolve
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemaDir, err := ioutil.TempDir(cwd, "schema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(schemaDir)
+	if err := GenerateTestMain("schema", schemaDir); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range testTables {
+		table := NewTable(tt.name)
+		for i, c := range tt.columns {
+			field, enums := genField(tt.name, c.name, c.datatype, c.columntype, table)
+			table.AddColumn(int64(i+1), c.name, c.key, c.datatype, c.columntype, c.def, c.maxlength, c.nullable, field, enums, c.extra)
+		}
+		if err := table.Configure(testConfig.Table(tt.name)); err != nil {
+			t.Fatal(err)
+		}
+		if err := table.GenerateORMToDir("schema", schemaDir); err != nil {
+			t.Fatal(err)
+		}
+		if err := table.GenerateORMTestCaseToDir("schema", schemaDir); err != nil {
+			t.Fatal(err)
+		}
+		if err := generateTestProtobuf(table, schemaDir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, schemaDir, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := pkgs["schema"]
+	if pkg == nil {
+		t.Fatal("the generated package should have been named schema")
+	}
+	files := make([]*ast.File, 0)
+	for _, f := range pkg.Files {
+		files = append(files, f)
+	}
+	errs := make([]string, 0)
+	config := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error: func(err error) {
+			errs = append(errs, err.Error())
+		},
+	}
+	schema, _ := config.Check("schema", fset, files, nil)
+	if len(errs) > 0 {
+		t.Fatalf("the generated code should have type checked but had errors:\n%s", strings.Join(errs, "\n"))
+	}
+	// a record read with a projection can only be written by the field-limited updates of its partial
+	for _, tt := range testTables {
+		partial := schema.Scope().Lookup("Partial" + CamelCase(tt.name))
+		if partial == nil {
+			t.Fatalf("a partial should have been generated for %s", tt.name)
+		}
+		methods := types.NewMethodSet(types.NewPointer(partial.Type()))
+		for i := 0; i < methods.Len(); i++ {
+			method := methods.At(i)
+			if len(method.Index()) > 1 {
+				t.Fatalf("the partial of %s should not have the method %s of its record", tt.name, method.Obj().Name())
+			}
+		}
+	}
+}
+
+func TestConfigureIDStrategy(t *testing.T) {
+	tests := []struct {
+		datatype, columntype string
+		maxlength            int64
+		strategy             string
+		valid                bool
+	}{
+		{"binary", "binary(16)", 16, "uuidv7", true},
+		{"binary", "binary(16)", 16, "hash", true},
+		{"binary", "binary(32)", 32, "hash", false},
+		{"varbinary", "varbinary(8)", 8, "ulid", false},
+		{"char", "char(36)", 36, "uuid", true},
+		{"char", "char(26)", 26, "uuid", false},
+		{"char", "char(26)", 26, "ulid", true},
+		{"char", "char(36)", 36, "hash", false},
+		{"char", "char(36)", 36, "auto", false},
+	}
+	for _, tt := range tests {
+		table := NewTable("keyed")
+		field, enums := genField("keyed", "id", tt.datatype, tt.columntype, table)
+		table.AddColumn(1, "id", "PRI", tt.datatype, tt.columntype, "", tt.maxlength, false, field, enums, "")
+		err := table.Configure(&TableConfig{IDStrategy: tt.strategy})
+		if tt.valid && err != nil {
+			t.Fatalf("id strategy %s should have been valid for %s but was %v", tt.strategy, tt.columntype, err)
+		}
+		if tt.valid == false && err == nil {
+			t.Fatalf("id strategy %s should have been invalid for %s", tt.strategy, tt.columntype)
+		}
+	}
+}
+
+func TestConfigureJSONType(t *testing.T) {
+	tests := []struct {
+		jsontype *JSONType
+		valid    bool
+	}{
+		{&JSONType{Message: "google.protobuf.Struct"}, true},
+		{&JSONType{GoType: "map[string]interface{}"}, true},
+		{&JSONType{GoType: "settings.Options", GoImport: "github.com/acme/settings"}, true},
+		{&JSONType{Message: "google.protobuf.Struct", GoType: "map[string]interface{}"}, false},
+		{&JSONType{}, false},
+	}
+	for _, tt := range tests {
+		table := NewTable("doc")
+		field, enums := genField("doc", "data", "json", "json", table)
+		table.AddColumn(1, "data", "", "json", "json", "", 0, true, field, enums, "")
+		err := table.Configure(&TableConfig{JSONTypes: map[string]*JSONType{"data": tt.jsontype}})
+		if tt.valid && err != nil {
+			t.Fatalf("json type %+v should have been valid but was %v", tt.jsontype, err)
+		}
+		if tt.valid == false && err == nil {
+			t.Fatalf("json type %+v should have been invalid", tt.jsontype)
+		}
+		if tt.valid && tt.jsontype.GoType != "" {
+			column := table.columns[0]
+			if column.IsGoJSON() == false || column.IsTypedJSON() || column.prototype != "string" {
+				t.Fatalf("json column mapped to %s should have stayed a string with go accessors", tt.jsontype.GoType)
+			}
+		}
+	}
+}
@@ -150,8 +150,35 @@ func (t *table) GenerateORMTestCase(packageName string, writer io.Writer) error
 	codebuf.WriteString("\tdb := GetDatabase()\n")
 	codebuf.WriteString("\tCreate" + CamelCase(t.name) + "Table(ctx)\n")
 	codebuf.WriteString("\tDelete" + CamelCase(t.name) + "Table(ctx)\n")
+	createdat := t.GetCreatedAt()
+	updatedat := t.GetUpdatedAt()
+	softdelete := t.GetSoftDelete()
+	if createdat != nil || updatedat != nil {
+		// a fixed clock so that writing the same record twice doesn't change the managed timestamps
+		imports.Add("time")
+		imports.Add("github.com/jhaynie/dbgen/pkg/orm")
+		codebuf.WriteString("\torm.SetClock(orm.FixedClock(time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)))\n")
+		codebuf.WriteString("\tdefer orm.SetClock(nil)\n")
+	}
 	codebuf.WriteString("\t" + t.name + " := " + CamelCase(t.name) + "{}\n")
 	for _, column := range t.columns {
+		if column == createdat || column == updatedat || column == softdelete {
+			// set by the generated code
+			continue
+		}
+		if column.IsTypedJSON() {
+			// decode an empty document so that the message is allocated without knowing its go type
+			imports.Add("database/sql")
+			imports.Add("github.com/jhaynie/dbgen/pkg/orm")
+			codebuf.WriteString("\tif err := orm.FromSQLJSON(\"" + column.name + "\", sql.NullString{String: \"{}\", Valid: true}, &" + t.name + "." + CamelCase(column.name) + "); err != nil {\n")
+			codebuf.WriteString("\t\tt.Fatal(err)\n")
+			codebuf.WriteString("\t}\n")
+			continue
+		}
+		if column.primarykey && t.idstrategy != "" {
+			// the key will be filled in by DBCreate
+			continue
+		}
 		codebuf.WriteString("\t" + t.name + "." + CamelCase(column.name) + " = ")
 		switch column.prototype {
 		case "string":
@@ -188,7 +215,8 @@ func (t *table) GenerateORMTestCase(packageName string, writer io.Writer) error
 			{
 				imports.Add("time")
 				imports.Add("github.com/go-sql-driver/mysql")
-				codebuf.WriteString(column.GenerateCast("mysql.NullTime{time.Now(),true}"))
+				imports.Add("github.com/jhaynie/dbgen/pkg/orm")
+				codebuf.WriteString(column.GenerateCast("mysql.NullTime{Time: time.Now(), Valid: true}"))
 			}
 		case "bytes":
 			{
@@ -206,8 +234,31 @@ func (t *table) GenerateORMTestCase(packageName string, writer io.Writer) error
 		}
 		codebuf.WriteString("\n")
 	}
-	codebuf.WriteString("\tr, err := " + t.name + ".DBCreate(ctx, db)\n")
-	codebuf.WriteString(`	if err != nil {
+	// each feature is checked in its own subtest so that a failure doesn't hide the others. the subtests share the record and run in order
+	var sub bytes.Buffer
+	subtest := func(name string, required bool) {
+		if required {
+			codebuf.WriteString("\tif t.Run(\"" + name + "\", func(t *testing.T) {\n")
+		} else {
+			codebuf.WriteString("\tt.Run(\"" + name + "\", func(t *testing.T) {\n")
+		}
+		for _, line := range strings.Split(strings.TrimRight(sub.String(), "\n"), "\n") {
+			if line != "" {
+				codebuf.WriteString("\t")
+			}
+			codebuf.WriteString(line + "\n")
+		}
+		if required {
+			codebuf.WriteString("\t}) == false {\n")
+			codebuf.WriteString("\t\treturn\n")
+			codebuf.WriteString("\t}\n")
+		} else {
+			codebuf.WriteString("\t})\n")
+		}
+		sub.Reset()
+	}
+	sub.WriteString("\tr, err := " + t.name + ".DBCreate(ctx, db)\n")
+	sub.WriteString(`	if err != nil {
 		t.Fatal(err)
 	}
 	rowCount, err := r.RowsAffected()
@@ -218,62 +269,235 @@ func (t *table) GenerateORMTestCase(packageName string, writer io.Writer) error
 		t.Fatalf("rowCount should have been 1 but was %d", rowCount)
 	}
 `)
+	for _, column := range []*column{createdat, updatedat} {
+		if column != nil {
+			sub.WriteString("\tif " + t.name + "." + CamelCase(column.name) + " == nil {\n")
+			sub.WriteString("\t\tt.Fatal(\"" + column.name + " should have been set by DBCreate\")\n")
+			sub.WriteString("\t}\n")
+		}
+	}
 	pk := t.GetPrimaryKey()
+	if pk != nil && t.idstrategy != "" {
+		if pk.prototype == "bytes" {
+			sub.WriteString("\tif len(" + t.name + "." + CamelCase(pk.name) + ") == 0 {\n")
+		} else {
+			sub.WriteString("\tif " + t.name + "." + CamelCase(pk.name) + " == " + pk.GenerateNullValue() + " {\n")
+		}
+		sub.WriteString("\t\tt.Fatal(\"primary key should have been set by DBCreate\")\n")
+		sub.WriteString("\t}\n")
+	}
+	subtest("Create", true)
 	if pk != nil {
-
-		codebuf.WriteString("\texists, err := " + t.name + ".DBExists(ctx, db)\n")
-		codebuf.WriteString(`	if err != nil {
+		imports.Add("errors")
+		imports.Add("github.com/jhaynie/dbgen/pkg/orm")
+		sub.WriteString("\tif _, err := " + t.name + ".DBCreate(ctx, db); errors.Is(err, orm.ErrDuplicateKey) == false {\n")
+		sub.WriteString("\t\tt.Fatalf(\"creating the record again should have returned orm.ErrDuplicateKey but was %v\", err)\n")
+		sub.WriteString("\t}\n")
+		subtest("DuplicateKey", false)
+		sub.WriteString("\texists, err := " + t.name + ".DBExists(ctx, db)\n")
+		sub.WriteString(`	if err != nil {
 		t.Fatal(err)
 	}
 	if exists == false {
 		t.Fatal("exists was false and should have been true")
 	}
 `)
-		codebuf.WriteString("\tcount, err := " + t.name + ".DBCount(ctx, db)\n")
-		codebuf.WriteString(`	if err != nil {
+		sub.WriteString("\tcount, err := " + t.name + ".DBCount(ctx, db)\n")
+		sub.WriteString(`	if err != nil {
 		t.Fatal(err)
 	}
 	if count != 1 {
 		t.Fatalf("could should have been 1 but was %d", count)
 	}
 `)
+		subtest("Exists", false)
+		imports.Add("github.com/jhaynie/dbgen/pkg/orm")
+		sub.WriteString("\titerated := 0\n")
+		sub.WriteString("\tif err := ForEach" + CamelCase(t.name) + "(ctx, db, func(r *" + CamelCase(t.name) + ") error {\n")
+		sub.WriteString(`		iterated++
+		return orm.ErrStop
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if iterated != 1 {
+		t.Fatalf("iterated should have been 1 but was %d", iterated)
+	}
+`)
+		subtest("ForEach", false)
+		pkfield := t.name + "." + CamelCase(pk.name)
+		sub.WriteString("\tjq, jp := orm.BuildQuery(append(" + CamelCase(t.name) + "Columns.As(\"a\"), orm.TableAlias(\"" + t.name + "\", \"a\"), orm.InnerJoin(orm.TableAlias(\"" + t.name + "\", \"b\"), orm.On(orm.TableColumn(\"a\", \"" + pk.name + "\"), orm.TableColumn(\"b\", \"" + pk.name + "\"))))...)\n")
+		sub.WriteString(`	joinRows, err := db.QueryContext(ctx, jq, jp...)
+	if err != nil {
+		t.Fatal(err)
+	}
+`)
+		sub.WriteString("\tjoined := &" + CamelCase(t.name) + "{}\n")
+		sub.WriteString(`	for joinRows.Next() {
+		if err := orm.ScanRow(joinRows, joined); err != nil {
+			t.Fatal(err)
+		}
+	}
+	joinRows.Close()
+`)
+		sub.WriteString("\tif orm.ToString(joined." + CamelCase(pk.name) + ") != orm.ToString(" + pkfield + ") {\n")
+		sub.WriteString("\t\tt.Fatal(\"the joined record should have been scanned\")\n")
+		sub.WriteString("\t}\n")
+		subtest("Join", false)
+		sub.WriteString("\tcluster := orm.NewCluster(db, orm.Replica{DB: db})\n")
+		sub.WriteString("\tclustered, err := " + t.pluralize("Count"+CamelCase(t.name)) + "(ctx, cluster)\n")
+		sub.WriteString(`	if err != nil {
+		t.Fatal(err)
+	}
+	if clustered != 1 {
+		t.Fatalf("count from the cluster should have been 1 but was %d", clustered)
+	}
+`)
+		subtest("Cluster", false)
+		sub.WriteString("\tpage, info, err := " + t.pluralize("Page"+CamelCase(t.name)) + "(ctx, db, 10, \"\", orm.OrderDef{})\n")
+		sub.WriteString(`	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 1 || info.Next != "" || info.Previous != "" {
+		t.Fatalf("page should have had 1 record and no cursors but had %d", len(page))
+	}
+`)
+		subtest("Page", false)
+		if pk.GenerateFilterField() != "" {
+			imports.Add("net/url")
+			sub.WriteString("\tfilters, err := " + CamelCase(t.name) + "FilterSchema.Parse(url.Values{\"" + pk.name + "\": {orm.ToString(" + pkfield + ")}, \"sort\": {\"-" + pk.name + "\"}})\n")
+			sub.WriteString(`	if err != nil {
+		t.Fatal(err)
+	}
+`)
+			sub.WriteString("\tfiltered, err := " + t.pluralize("Find"+CamelCase(t.name)) + "(ctx, db, filters...)\n")
+			sub.WriteString(`	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filtered) != 1 {
+		t.Fatalf("filters should have found 1 record but found %d", len(filtered))
+	}
+`)
+			subtest("Filter", false)
+		}
+		sub.WriteString("\tprojected, err := " + t.pluralize("Find"+CamelCase(t.name)) + "Partial(ctx, db, orm.Only(" + CamelCase(t.name) + "Field" + CamelCase(pk.name) + "), orm.IsEqual(\"" + pk.name + "\", " + pkfield + "))\n")
+		sub.WriteString(`	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projected) != 1 || len(projected[0].Fields) != 1 {
+		t.Fatalf("projection should have found 1 record with 1 field but found %d", len(projected))
+	}
+`)
+		sub.WriteString("\tif orm.ToString(projected[0]." + CamelCase(pk.name) + ") != orm.ToString(" + pkfield + ") {\n")
+		sub.WriteString("\t\tt.Fatal(\"the projected field should have been read\")\n")
+		sub.WriteString("\t}\n")
+		if len(t.columns) > 1 {
+			other := t.columns[0]
+			if other == pk {
+				other = t.columns[1]
+			}
+			sub.WriteString("\tif _, err := projected[0].DBUpdateFields(ctx, db, " + CamelCase(t.name) + "Field" + CamelCase(other.name) + "); err == nil {\n")
+			sub.WriteString("\t\tt.Fatal(\"updating a field which wasn't read should have failed\")\n")
+			sub.WriteString("\t}\n")
+		}
+		sub.WriteString("\tif _, err := " + t.pluralize("Find"+CamelCase(t.name)) + "(ctx, db, orm.Only(" + CamelCase(t.name) + "Field" + CamelCase(pk.name) + ")); errors.Is(err, orm.ErrInvalidQuery) == false {\n")
+		sub.WriteString("\t\tt.Fatalf(\"find with a projection should have returned orm.ErrInvalidQuery but was %v\", err)\n")
+		sub.WriteString("\t}\n")
+		sub.WriteString("\tif _, err := (&" + CamelCase(t.name) + "{}).DBFind(ctx, db, orm.Only(" + CamelCase(t.name) + "Field" + CamelCase(pk.name) + ")); errors.Is(err, orm.ErrInvalidQuery) == false {\n")
+		sub.WriteString("\t\tt.Fatalf(\"DBFind with a projection should have returned orm.ErrInvalidQuery but was %v\", err)\n")
+		sub.WriteString("\t}\n")
+		subtest("Partial", false)
+		if pk.GenerateGroupType() != "" {
+			sub.WriteString("\tgrouped, err := " + t.pluralize("Count"+CamelCase(t.name)) + "By(ctx, db, []" + CamelCase(t.name) + "Field{" + CamelCase(t.name) + "Field" + CamelCase(pk.name) + "})\n")
+			sub.WriteString(`	if err != nil {
+		t.Fatal(err)
+	}
+	if len(grouped) != 1 {
+		t.Fatalf("grouped count should have had 1 group but had %d", len(grouped))
+	}
+`)
+			// a binary key is grouped as a string
+			groupkey := "orm.ToString(" + pkfield + ")"
+			if pk.prototype == "bytes" {
+				groupkey = "string(" + pkfield + ")"
+			}
+			sub.WriteString("\tfor group, count := range grouped {\n")
+			sub.WriteString("\t\tif count != 1 || orm.ToString(group." + CamelCase(pk.name) + ") != " + groupkey + " {\n")
+			sub.WriteString("\t\t\tt.Fatalf(\"grouped count should have had 1 record in the group of the primary key but had %d\", count)\n")
+			sub.WriteString("\t\t}\n")
+			sub.WriteString("\t}\n")
+			subtest("Aggregate", false)
+		}
+		pkcondition := "orm.IsEqual(\"" + pk.name + "\", " + pkfield + ")"
+		if pk.GenerateColumnType() != "orm.Col" {
+			pkcondition = CamelCase(t.name) + "Columns." + CamelCase(pk.name) + ".Eq(" + pkfield + ")"
+		}
+		sub.WriteString("\tchanged, err := " + t.pluralize("Update"+CamelCase(t.name)) + "(ctx, db, []orm.AssignmentDef{orm.SetExprDef(\"" + pk.name + "\", orm.Expr(\"`" + pk.name + "`\"))}, " + pkcondition + ")\n")
+		sub.WriteString(`	if err != nil {
+		t.Fatal(err)
+	}
+	if changed > 1 {
+		t.Fatalf("update should have changed at most 1 record but changed %d", changed)
+	}
+`)
+		subtest("UpdateMany", false)
 		codebuf.WriteString("\toldpk := " + t.name + "." + CamelCase(pk.name) + "\n")
-		codebuf.WriteString("\tdeleted, err := " + t.name + ".DBDelete(ctx, db)\n")
-		codebuf.WriteString(`	if err != nil {
+		sub.WriteString("\tdeleted, err := " + t.name + ".DBDelete(ctx, db)\n")
+		sub.WriteString(`	if err != nil {
 		t.Fatal(err)
 	}
 	if deleted == false {
 		t.Fatal("record was not deleted")
 	}
 `)
-		codebuf.WriteString("\tfound, err := " + t.name + ".DBFindOne(ctx, db, " + t.name + "." + CamelCase(pk.name) + ")\n")
-		codebuf.WriteString(`	if err != nil {
+		sub.WriteString("\tfound, err := " + t.name + ".DBFindOne(ctx, db, " + t.name + "." + CamelCase(pk.name) + ")\n")
+		sub.WriteString(`	if err != nil {
 		t.Fatal(err)
 	}
 	if found {
 		t.Fatal("record was found and it should have been deleted")
 	}
 `)
-		codebuf.WriteString("\texists, err = " + t.name + ".DBExists(ctx, db)\n")
-		codebuf.WriteString(`	if err != nil {
+		sub.WriteString("\texists, err := " + t.name + ".DBExists(ctx, db)\n")
+		sub.WriteString(`	if err != nil {
 		t.Fatal(err)
 	}
 	if exists {
 		t.Fatal("exists was true and should have been false")
 	}
 `)
-		codebuf.WriteString("\tcount, err = " + t.name + ".DBCount(ctx, db)\n")
-		codebuf.WriteString(`	if err != nil {
+		sub.WriteString("\tcount, err := " + t.name + ".DBCount(ctx, db)\n")
+		sub.WriteString(`	if err != nil {
 		t.Fatal(err)
 	}
 	if count != 0 {
 		t.Fatalf("could should have been 0 but was %d", count)
 	}
 `)
+		if softdelete != nil {
+			imports.Add("github.com/jhaynie/dbgen/pkg/orm")
+			sub.WriteString("\tfound, err = " + t.name + ".DBFindOne(ctx, db, oldpk, orm.OnlyDeleted())\n")
+			sub.WriteString(`	if err != nil {
+		t.Fatal(err)
+	}
+	if found == false {
+		t.Fatal("soft deleted record should have been found")
+	}
+`)
+			sub.WriteString("\tdeleted, err = " + t.name + ".DBHardDelete(ctx, db)\n")
+			sub.WriteString(`	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted == false {
+		t.Fatal("record was not hard deleted")
+	}
+`)
+			sub.WriteString("\t" + t.name + "." + CamelCase(softdelete.name) + " = nil\n")
+		}
+		subtest("Delete", false)
 		codebuf.WriteString("\t// reset since delete will nullify it\n")
 		codebuf.WriteString("\t" + t.name + "." + CamelCase(pk.name) + " = oldpk\n")
-		codebuf.WriteString("\tinserted, updated, err := " + t.name + ".DBUpsert(ctx, db)\n")
-		codebuf.WriteString(`	if err != nil {
+		sub.WriteString("\tinserted, updated, err := " + t.name + ".DBUpsert(ctx, db)\n")
+		sub.WriteString(`	if err != nil {
 		t.Fatal(err)
 	}
 	if inserted == false {
@@ -283,8 +507,8 @@ func (t *table) GenerateORMTestCase(packageName string, writer io.Writer) error
 		t.Fatal("upsert should return updated = false but was true")
 	}
 `)
-		codebuf.WriteString("\tinserted, updated, err = " + t.name + ".DBUpsert(ctx, db)\n")
-		codebuf.WriteString(`	if err != nil {
+		sub.WriteString("\tinserted, updated, err = " + t.name + ".DBUpsert(ctx, db)\n")
+		sub.WriteString(`	if err != nil {
 		t.Fatal(err)
 	}
 	if inserted {
@@ -295,8 +519,8 @@ func (t *table) GenerateORMTestCase(packageName string, writer io.Writer) error
 	}
 
 `)
-		codebuf.WriteString("\tfound, err = " + t.name + ".DBFindOne(ctx, db, oldpk)\n")
-		codebuf.WriteString(`	if err != nil {
+		sub.WriteString("\tfound, err := " + t.name + ".DBFindOne(ctx, db, oldpk)\n")
+		sub.WriteString(`	if err != nil {
 		t.Fatal(err)
 	}
 	if found == false {
@@ -304,6 +528,92 @@ func (t *table) GenerateORMTestCase(packageName string, writer io.Writer) error
 	}
 
 `)
+		subtest("Upsert", false)
+		imports.Add("github.com/jhaynie/dbgen/pkg/orm")
+		sub.WriteString("\ttracker := New" + CamelCase(t.name) + "Tracker(&" + t.name + ")\n")
+		sub.WriteString(`	if changed := tracker.Changed(); len(changed) > 0 {
+		t.Fatalf("tracker should have no changes but was %v", changed)
+	}
+`)
+		sub.WriteString("\t_, err := " + t.name + ".DBUpdateMask(ctx, db, orm.Paths{\"not_a_column\"})\n")
+		sub.WriteString(`	if _, ok := err.(*orm.FieldError); ok == false {
+		t.Fatalf("update of an unknown field should have returned a *orm.FieldError but was %v", err)
+	}
+
+`)
+		if checksum := t.GetChecksum(); checksum != nil {
+			for _, column := range t.columns {
+				if column.primarykey || column.IsChecksum() {
+					continue
+				}
+				sub.WriteString("\tif _, err := " + t.name + ".DBUpdateFields(ctx, db, " + CamelCase(t.name) + "Field" + CamelCase(column.name) + "); err != nil {\n")
+				sub.WriteString("\t\tt.Fatal(err)\n")
+				sub.WriteString("\t}\n")
+				sub.WriteString("\tif dirty, _ := " + t.name + ".DBIsDirty(); dirty == false {\n")
+				sub.WriteString("\t\tt.Fatal(\"the record should be dirty after a partial update\")\n")
+				sub.WriteString("\t}\n")
+				break
+			}
+		}
+		subtest("Tracker", false)
+		if version := t.GetVersion(); version != nil {
+			imports.Add("github.com/jhaynie/dbgen/pkg/orm")
+			if checksum := t.GetChecksum(); checksum != nil {
+				sub.WriteString("\t// clear the checksum so that the record is dirty\n")
+				sub.WriteString("\t" + t.name + "." + CamelCase(checksum.name) + " = \"\"\n")
+			}
+			sub.WriteString("\tstale := " + t.name + "\n")
+			sub.WriteString("\t_, err := " + t.name + ".DBUpdateOptimistic(ctx, db)\n")
+			sub.WriteString(`	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = stale.DBUpdateOptimistic(ctx, db)
+	if err != orm.ErrConflict {
+		t.Fatalf("stale update should have returned orm.ErrConflict but was %v", err)
+	}
+`)
+			for _, column := range t.columns {
+				if column.primarykey || column.IsChecksum() || column == version {
+					continue
+				}
+				sub.WriteString("\t// a partial update increments the version so that a stale optimistic update still conflicts\n")
+				sub.WriteString("\tstale = " + t.name + "\n")
+				if checksum := t.GetChecksum(); checksum != nil {
+					sub.WriteString("\tstale." + CamelCase(checksum.name) + " = \"\"\n")
+				}
+				sub.WriteString("\tif _, err := " + t.name + ".DBUpdateFields(ctx, db, " + CamelCase(t.name) + "Field" + CamelCase(column.name) + "); err != nil {\n")
+				sub.WriteString("\t\tt.Fatal(err)\n")
+				sub.WriteString("\t}\n")
+				sub.WriteString(`	_, err = stale.DBUpdateOptimistic(ctx, db)
+	if err != orm.ErrConflict {
+		t.Fatalf("stale update after a partial update should have returned orm.ErrConflict but was %v", err)
+	}
+`)
+				break
+			}
+			subtest("Optimistic", false)
+		}
+	}
+
+	if softdelete != nil {
+		imports.Add("github.com/jhaynie/dbgen/pkg/orm")
+		count := t.pluralize("Count" + CamelCase(t.name))
+		sub.WriteString("\tif err := " + t.pluralize("DeleteAll"+CamelCase(t.name)) + "(ctx, db); err != nil {\n")
+		sub.WriteString("\t\tt.Fatal(err)\n")
+		sub.WriteString("\t}\n")
+		sub.WriteString("\tif count, err := " + count + "(ctx, db); err != nil || count != 0 {\n")
+		sub.WriteString("\t\tt.Fatalf(\"count after deleting all should have been 0 but was %d (%v)\", count, err)\n")
+		sub.WriteString("\t}\n")
+		sub.WriteString("\tif count, err := " + count + "(ctx, db, orm.OnlyDeleted()); err != nil || count == 0 {\n")
+		sub.WriteString("\t\tt.Fatalf(\"deleting all should have soft deleted the records but %d were deleted (%v)\", count, err)\n")
+		sub.WriteString("\t}\n")
+		sub.WriteString("\tif err := " + t.pluralize("HardDeleteAll"+CamelCase(t.name)) + "(ctx, db); err != nil {\n")
+		sub.WriteString("\t\tt.Fatal(err)\n")
+		sub.WriteString("\t}\n")
+		sub.WriteString("\tif count, err := " + count + "(ctx, db, orm.WithDeleted()); err != nil || count != 0 {\n")
+		sub.WriteString("\t\tt.Fatalf(\"count after hard deleting all should have been 0 but was %d (%v)\", count, err)\n")
+		sub.WriteString("\t}\n")
+		subtest("DeleteAll", false)
 	}
 
 	codebuf.WriteString("}\n")
@@ -0,0 +1,153 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// MaxPlaceholders is the most placeholders MySQL allows in a single prepared statement
+const MaxPlaceholders = 65535
+
+// DefaultMaxAllowedPacket is the packet size used when the max_allowed_packet of the server can't be read
+const DefaultMaxAllowedPacket = 4 * 1024 * 1024
+
+// BatchResult is the result of one multi-row statement of a batch
+type BatchResult struct {
+	// Offset is the index of the first row of the statement
+	Offset int
+	// Count is the number of rows in the statement
+	Count int
+	// Result is the result of executing the statement
+	Result sql.Result
+}
+
+// BatchError is returned when a statement of a batch fails. the statements before it were rolled back when the batch ran in its own transaction and have otherwise already been executed
+type BatchError struct {
+	Offset int
+	Count  int
+	Err    error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("orm: error executing batch of %d rows at offset %d: %v", e.Count, e.Offset, e.Err)
+}
+
+// Unwrap returns the error from executing the statement
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// MaxAllowedPacket returns the max_allowed_packet of the server
+func MaxAllowedPacket(ctx context.Context, db Executor) (int, error) {
+	var size sql.NullInt64
+	if err := db.QueryRowContext(ctx, "SELECT @@max_allowed_packet").Scan(&size); err != nil {
+		return 0, err
+	}
+	return int(size.Int64), nil
+}
+
+// estimate of the number of bytes a parameter takes in the packet
+func paramSize(v interface{}) int {
+	switch p := v.(type) {
+	case string:
+		return len(p)
+	case []byte:
+		return len(p)
+	case sql.NullString:
+		return len(p.String)
+	}
+	return 16
+}
+
+// ExecBatch executes the rows using multi-row statements of the form prefix row,row,... suffix. the rows are split into statements
+// so that each is within the placeholder limit and the max_allowed_packet of the server. when there is more than one statement and
+// db can begin a transaction the statements are run in one with WithTx so that the batch is atomic. when db is a *sql.Tx the
+// statements are run in it and the caller decides whether to commit the statements before a failure
+func ExecBatch(ctx context.Context, db Executor, prefix string, row string, suffix string, rows [][]interface{}) ([]BatchResult, error) {
+	if len(rows) == 0 {
+		return make([]BatchResult, 0), nil
+	}
+	maxPacket, err := MaxAllowedPacket(ctx, db)
+	if err != nil || maxPacket <= 0 {
+		maxPacket = DefaultMaxAllowedPacket
+	}
+	return ExecBatchSize(ctx, db, maxPacket, prefix, row, suffix, rows)
+}
+
+// ExecBatchSize is the same as ExecBatch using maxPacket instead of reading the max_allowed_packet from the server
+func ExecBatchSize(ctx context.Context, db Executor, maxPacket int, prefix string, row string, suffix string, rows [][]interface{}) ([]BatchResult, error) {
+	statements := splitBatch(maxPacket, prefix, row, suffix, rows)
+	if _, ok := db.(TxBeginner); ok && len(statements) > 1 {
+		var results []BatchResult
+		err := WithTx(ctx, db, nil, func(tx *sql.Tx) error {
+			var err error
+			results, err = execBatch(ctx, tx, statements)
+			return err
+		})
+		if err != nil {
+			// the statements which were executed have been rolled back
+			return make([]BatchResult, 0), err
+		}
+		return results, nil
+	}
+	return execBatch(ctx, db, statements)
+}
+
+// batchStatement is one multi-row statement of a batch
+type batchStatement struct {
+	offset int
+	count  int
+	query  string
+	params []interface{}
+}
+
+// splitBatch splits the rows into statements which are within the placeholder limit and maxPacket
+func splitBatch(maxPacket int, prefix string, row string, suffix string, rows [][]interface{}) []batchStatement {
+	statements := make([]batchStatement, 0)
+	// leave room for the packet header and the encoding of the parameters
+	maxPacket = maxPacket - 1024
+	base := len(prefix) + len(suffix) + 1
+	offset := 0
+	for offset < len(rows) {
+		size := base
+		placeholders := 0
+		count := 0
+		for i := offset; i < len(rows); i++ {
+			rowSize := len(row) + 1
+			for _, v := range rows[i] {
+				rowSize += paramSize(v)
+			}
+			if count > 0 && (placeholders+len(rows[i]) > MaxPlaceholders || size+rowSize > maxPacket) {
+				break
+			}
+			size += rowSize
+			placeholders += len(rows[i])
+			count++
+		}
+		params := make([]interface{}, 0, placeholders)
+		for _, r := range rows[offset : offset+count] {
+			params = append(params, r...)
+		}
+		q := prefix + strings.TrimSuffix(strings.Repeat(row+",", count), ",")
+		if suffix != "" {
+			q += " " + suffix
+		}
+		statements = append(statements, batchStatement{offset, count, q, params})
+		offset += count
+	}
+	return statements
+}
+
+func execBatch(ctx context.Context, db Executor, statements []batchStatement) ([]BatchResult, error) {
+	results := make([]BatchResult, 0, len(statements))
+	for _, statement := range statements {
+		r, err := db.ExecContext(ctx, statement.query, statement.params...)
+		if err != nil {
+			return results, &BatchError{statement.offset, statement.count, WrapError(err)}
+		}
+		results = append(results, BatchResult{statement.offset, statement.count, r})
+	}
+	return results, nil
+}
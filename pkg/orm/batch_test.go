@@ -0,0 +1,122 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type batchExecutor struct {
+	queries []string
+	params  [][]interface{}
+	err     error
+}
+
+func (e *batchExecutor) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	e.queries = append(e.queries, query)
+	e.params = append(e.params, args)
+	return nil, e.err
+}
+
+func (e *batchExecutor) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func (e *batchExecutor) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	panic("not supported")
+}
+
+func TestExecBatchEmpty(t *testing.T) {
+	assert := assert.New(t)
+	results, err := ExecBatch(context.Background(), &batchExecutor{}, "INSERT INTO `a` (`b`) VALUES ", "(?)", "", nil)
+	assert.Nil(err)
+	assert.Len(results, 0)
+}
+
+func TestParamSize(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(3, paramSize("abc"))
+	assert.Equal(2, paramSize([]byte{1, 2}))
+	assert.Equal(4, paramSize(sql.NullString{String: "abcd", Valid: true}))
+	assert.Equal(16, paramSize(1))
+}
+
+func TestBatchError(t *testing.T) {
+	assert := assert.New(t)
+	err := &BatchError{10, 5, errors.New("boom")}
+	assert.Equal("orm: error executing batch of 5 rows at offset 10: boom", err.Error())
+	assert.Equal("boom", err.Unwrap().Error())
+}
+
+func TestExecBatchSize(t *testing.T) {
+	assert := assert.New(t)
+	rows := [][]interface{}{{"a", 1}, {"b", 2}, {"c", 3}}
+	e := &batchExecutor{}
+	results, err := ExecBatchSize(context.Background(), e, DefaultMaxAllowedPacket, "INSERT INTO `a` (`b`,`c`) VALUES ", "(?,?)", "ON DUPLICATE KEY UPDATE `c` = VALUES(`c`)", rows)
+	assert.Nil(err)
+	assert.Len(results, 1)
+	assert.Equal(3, results[0].Count)
+	assert.Equal([]string{"INSERT INTO `a` (`b`,`c`) VALUES (?,?),(?,?),(?,?) ON DUPLICATE KEY UPDATE `c` = VALUES(`c`)"}, e.queries)
+	assert.Equal([]interface{}{"a", 1, "b", 2, "c", 3}, e.params[0])
+
+	// a packet which only fits one row per statement
+	e = &batchExecutor{}
+	results, err = ExecBatchSize(context.Background(), e, 1024+60, "INSERT INTO `a` (`b`,`c`) VALUES ", "(?,?)", "", rows)
+	assert.Nil(err)
+	assert.Len(results, 3)
+	assert.Equal(2, results[2].Offset)
+	assert.Equal(1, results[2].Count)
+	assert.Equal("INSERT INTO `a` (`b`,`c`) VALUES (?,?)", e.queries[0])
+
+	e = &batchExecutor{err: errors.New("boom")}
+	results, err = ExecBatchSize(context.Background(), e, DefaultMaxAllowedPacket, "INSERT INTO `a` (`b`,`c`) VALUES ", "(?,?)", "", rows)
+	assert.NotNil(err)
+	assert.Len(results, 0)
+	berr, ok := err.(*BatchError)
+	assert.True(ok)
+	assert.Equal(3, berr.Count)
+}
+
+type batchBeginner struct {
+	batchExecutor
+}
+
+func (e *batchBeginner) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return nil, errors.New("begin")
+}
+
+func TestExecBatchTx(t *testing.T) {
+	assert := assert.New(t)
+	rows := [][]interface{}{{"a", 1}, {"b", 2}}
+
+	// more than one statement is run in a transaction
+	e := &batchBeginner{}
+	results, err := ExecBatchSize(context.Background(), e, 1024+60, "INSERT INTO `a` (`b`,`c`) VALUES ", "(?,?)", "", rows)
+	assert.EqualError(err, "begin")
+	assert.Len(results, 0)
+	assert.Len(e.queries, 0)
+
+	// a single statement doesn't need one
+	e = &batchBeginner{}
+	results, err = ExecBatchSize(context.Background(), e, DefaultMaxAllowedPacket, "INSERT INTO `a` (`b`,`c`) VALUES ", "(?,?)", "", rows)
+	assert.Nil(err)
+	assert.Len(results, 1)
+	assert.Len(e.queries, 1)
+}
+
+func TestExecBatchPlaceholders(t *testing.T) {
+	assert := assert.New(t)
+	rows := make([][]interface{}, 0)
+	for i := 0; i < 40000; i++ {
+		rows = append(rows, []interface{}{i, i})
+	}
+	e := &batchExecutor{}
+	results, err := ExecBatchSize(context.Background(), e, 1<<30, "INSERT INTO `a` (`b`,`c`) VALUES ", "(?,?)", "", rows)
+	assert.Nil(err)
+	assert.Len(results, 2)
+	assert.Equal(32767, results[0].Count)
+	assert.Equal(40000-32767, results[1].Count)
+}
@@ -0,0 +1,344 @@
+package orm
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// clause is a part of a SELECT in the order it's written
+type clause int
+
+const (
+	clauseSelect clause = iota
+	clauseFrom
+	clauseJoin
+	clauseWhere
+	clauseGroupBy
+	clauseHaving
+	clauseUnion
+	clauseOrderBy
+	clauseLimit
+)
+
+var clauseNames = []string{"SELECT", "FROM", "JOIN", "WHERE", "GROUP BY", "HAVING", "UNION", "ORDER BY", "LIMIT"}
+
+// Builder builds a SELECT from typed clauses which are always written in SQL order. the first misuse such as a clause added out of order or a second LIMIT is returned by Build
+type Builder struct {
+	distinct bool
+	columns  []ColumnDef
+	tables   []TableDef
+	joins    []JoinTableDef
+	where    []Condition
+	groups   []GroupDef
+	having   []HavingDef
+	unions   []UnionDef
+	orders   []OrderDef
+	limit    interface{}
+	clause   clause
+	err      error
+}
+
+// Select returns a builder for a query of the columns
+func Select(columns ...ColumnDef) *Builder {
+	return &Builder{columns: columns}
+}
+
+// Query returns a builder for the components accepted by BuildQuery in any order
+func Query(components ...interface{}) *Builder {
+	return (&Builder{}).Add(components...)
+}
+
+// fail records the first misuse which is returned by Build
+func (b *Builder) fail(format string, args ...interface{}) {
+	if b.err == nil {
+		b.err = &QueryError{fmt.Sprintf(format, args...)}
+	}
+}
+
+// at moves the builder to the clause and fails if a later clause was already added
+func (b *Builder) at(c clause) {
+	if c < b.clause {
+		b.fail("%s can't be added after %s", clauseNames[c], clauseNames[b.clause])
+		return
+	}
+	b.clause = c
+}
+
+// Add adds the components accepted by BuildQuery to their clauses without checking the order they're in
+func (b *Builder) Add(components ...interface{}) *Builder {
+	for _, component := range components {
+		switch c := component.(type) {
+		case DistinctDef:
+			b.distinct = true
+		case ColumnDef:
+			b.columns = append(b.columns, c)
+		case TableDef:
+			b.tables = append(b.tables, c)
+		case JoinTableDef:
+			b.joins = append(b.joins, c)
+		case ConditionDef, ConditionGroupDef, NotDef, KeysetDef, JoinDef, ExprDef:
+			b.where = append(b.where, c.(Condition))
+		case GroupDef:
+			b.groups = append(b.groups, c)
+		case HavingDef:
+			b.having = append(b.having, c)
+		case UnionDef:
+			b.unions = append(b.unions, c)
+		case OrderDef:
+			b.orders = append(b.orders, c)
+		case LimitDef:
+			b.setLimit(c, c.Total >= 0)
+		case RangeDef:
+			b.setLimit(c, c.Offset >= 0 && c.Max >= 0)
+		default:
+			b.fail("unsupported component %T", component)
+		}
+	}
+	return b
+}
+
+// setLimit keeps the first LIMIT and fails on any other
+func (b *Builder) setLimit(limit interface{}, valid bool) {
+	if b.limit != nil {
+		b.fail("LIMIT can only be set once")
+		return
+	}
+	if valid == false {
+		b.fail("LIMIT can't be negative")
+		return
+	}
+	b.limit = limit
+}
+
+// Distinct removes the duplicate rows from the results
+func (b *Builder) Distinct() *Builder {
+	b.distinct = true
+	return b
+}
+
+// From adds the tables to select from
+func (b *Builder) From(tables ...TableDef) *Builder {
+	b.at(clauseFrom)
+	b.tables = append(b.tables, tables...)
+	return b
+}
+
+// Join adds joins such as those returned by InnerJoin
+func (b *Builder) Join(joins ...JoinTableDef) *Builder {
+	b.at(clauseJoin)
+	b.joins = append(b.joins, joins...)
+	return b
+}
+
+// Where adds conditions which are joined with AND
+func (b *Builder) Where(conditions ...Condition) *Builder {
+	b.at(clauseWhere)
+	for _, c := range conditions {
+		if c == nil {
+			b.fail("WHERE condition can't be nil")
+			continue
+		}
+		b.where = append(b.where, c)
+	}
+	return b
+}
+
+// GroupBy adds groupings such as those returned by GroupBy and GroupByExpr
+func (b *Builder) GroupBy(groups ...GroupDef) *Builder {
+	b.at(clauseGroupBy)
+	b.groups = append(b.groups, groups...)
+	return b
+}
+
+// Having adds conditions on the groups which are joined with AND
+func (b *Builder) Having(conditions ...Condition) *Builder {
+	b.at(clauseHaving)
+	b.having = append(b.having, Having(conditions...))
+	return b
+}
+
+// Union adds a UNION with the query which removes the duplicate rows
+func (b *Builder) Union(q *Builder) *Builder {
+	return b.union(q, false)
+}
+
+// UnionAll adds a UNION ALL with the query which keeps the duplicate rows
+func (b *Builder) UnionAll(q *Builder) *Builder {
+	return b.union(q, true)
+}
+
+func (b *Builder) union(q *Builder, all bool) *Builder {
+	b.at(clauseUnion)
+	query, params, err := q.Build()
+	if err != nil {
+		b.fail("UNION query %v", err)
+		return b
+	}
+	b.unions = append(b.unions, UnionDef{all, SubqueryDef{Query: query, Params: params}})
+	return b
+}
+
+// OrderBy adds orders such as those returned by Ascending and Descending
+func (b *Builder) OrderBy(orders ...OrderDef) *Builder {
+	b.at(clauseOrderBy)
+	b.orders = append(b.orders, orders...)
+	return b
+}
+
+// Limit sets the maximum number of rows
+func (b *Builder) Limit(max int32) *Builder {
+	b.at(clauseLimit)
+	b.setLimit(Limit(max), max >= 0)
+	return b
+}
+
+// Range sets the offset and maximum number of rows
+func (b *Builder) Range(offset, max int32) *Builder {
+	b.at(clauseLimit)
+	b.setLimit(Range(offset, max), offset >= 0 && max >= 0)
+	return b
+}
+
+// validate fails for clauses which can't be used without another clause and for columns, orders, joins and subqueries which aren't written as is
+func (b *Builder) validate() error {
+	for _, c := range b.columns {
+		if c.validFunc() == false {
+			b.fail("%s can't be applied to a column", c.function())
+		}
+	}
+	for _, o := range b.orders {
+		if o.Name == "" || (o.Direction != DirectionAscending && o.Direction != DirectionDescending) {
+			b.fail("ORDER BY needs a column and a direction")
+		}
+	}
+	conditions := append([]Condition{}, b.where...)
+	for _, j := range b.joins {
+		conditions = append(conditions, j.On...)
+	}
+	for _, h := range b.having {
+		conditions = append(conditions, h.Conditions...)
+	}
+	for _, c := range conditions {
+		if err := conditionError(c); err != nil && b.err == nil {
+			b.err = err
+		}
+	}
+	for _, u := range b.unions {
+		if u.Query.err != nil {
+			b.fail("UNION query %v", u.Query.err)
+		}
+	}
+	switch {
+	case len(b.joins) > 0 && len(b.tables) == 0:
+		b.fail("JOIN needs a FROM")
+	case len(b.tables) > 0 && len(b.columns) == 0:
+		b.fail("FROM needs columns to SELECT")
+	case len(b.unions) > 0 && len(b.columns) == 0:
+		b.fail("UNION needs columns to SELECT")
+	}
+	return b.err
+}
+
+// Build returns the query and its parameters or the first misuse of the builder
+func (b *Builder) Build() (string, []interface{}, error) {
+	if err := b.validate(); err != nil {
+		return "", nil, err
+	}
+	q, p := b.build()
+	return q, p, nil
+}
+
+// Count returns a query for the number of rows the query returns ignoring its ORDER BY and LIMIT
+func (b *Builder) Count() (string, []interface{}, error) {
+	c := *b
+	c.orders = nil
+	c.limit = nil
+	if c.distinct || len(c.groups) > 0 || len(c.unions) > 0 {
+		// the rows can only be counted after they're grouped or combined
+		q, p, err := c.Build()
+		if err != nil {
+			return "", nil, err
+		}
+		return "SELECT COUNT(*) FROM (" + q + ") AS `count`", p, nil
+	}
+	c.columns = []ColumnDef{Count("*")}
+	return c.Build()
+}
+
+func (b *Builder) build() (string, []interface{}) {
+	var buf bytes.Buffer
+	params := make([]interface{}, 0)
+	if len(b.columns) > 0 {
+		buf.WriteString("SELECT ")
+		if b.distinct {
+			buf.WriteString("DISTINCT ")
+		}
+		for i, c := range b.columns {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(c.String())
+			params = append(params, c.Expression.Params...)
+		}
+	}
+	for i, t := range b.tables {
+		if i == 0 {
+			buf.WriteString(" FROM ")
+		} else {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(t.String())
+	}
+	for _, j := range b.joins {
+		buf.WriteString(" " + j.String())
+		params = j.AddValue(params)
+	}
+	for i, c := range b.where {
+		if i == 0 {
+			buf.WriteString(" WHERE ")
+		} else {
+			buf.WriteString(" AND ")
+		}
+		buf.WriteString(c.String())
+		params = c.AddValue(params)
+	}
+	for i, g := range b.groups {
+		if i == 0 {
+			buf.WriteString(" GROUP BY ")
+		} else {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(g.String())
+		params = g.AddValue(params)
+	}
+	for i, h := range b.having {
+		if i == 0 {
+			buf.WriteString(" HAVING ")
+		} else {
+			buf.WriteString(" AND ")
+		}
+		buf.WriteString(h.String())
+		params = h.AddValue(params)
+	}
+	for _, u := range b.unions {
+		buf.WriteString(" " + u.String())
+		params = u.AddValue(params)
+	}
+	for i, o := range b.orders {
+		if i == 0 {
+			buf.WriteString(" ORDER BY ")
+		} else {
+			buf.WriteString(",")
+		}
+		buf.WriteString(o.String())
+		buf.WriteString(" ")
+	}
+	if b.limit != nil {
+		if strings.HasSuffix(buf.String(), " ") == false {
+			buf.WriteString(" ")
+		}
+		buf.WriteString(b.limit.(fmt.Stringer).String())
+	}
+	return strings.TrimSpace(buf.String()), params
+}
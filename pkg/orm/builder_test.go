@@ -0,0 +1,106 @@
+package orm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder(t *testing.T) {
+	assert := assert.New(t)
+	q, p, err := Select(Column("id"), Column("name")).
+		From(TableAlias("user", "u")).
+		Join(LeftJoin(TableAlias("repo", "r"), On(TableColumn("r", "user_id"), TableColumn("u", "id")))).
+		Where(IsEqual("active", true), OrGrouping(IsNull("email"), IsLike("email", LikeSuffix("@example.com")))).
+		OrderBy(Ascending("name"), Descending("id")).
+		Range(20, 10).
+		Build()
+	assert.Nil(err)
+	assert.Equal("SELECT `id`, `name` FROM `user` `u` LEFT JOIN `repo` `r` ON `r`.`user_id` = `u`.`id` WHERE `active` = ? AND (`email` IS NULL OR `email` LIKE ?) ORDER BY `name` ASC ,`id` DESC LIMIT 20,10", q)
+	assert.Equal([]interface{}{true, "%@example.com"}, p)
+
+	q, p, err = Select(Column("kind"), CountAlias("*", "total")).From(Table("event")).Distinct().
+		GroupBy(GroupBy("kind")).
+		Having(IsGreaterThanExpr("COUNT(*)", 1)).
+		UnionAll(Select(Column("kind"), ColumnExprDefAlias(Expr("?", 0), "total")).From(Table("archive"))).
+		Limit(5).
+		Build()
+	assert.Nil(err)
+	assert.Equal("SELECT DISTINCT `kind`, COUNT(*) AS `total` FROM `event` GROUP BY `kind` HAVING (COUNT(*) > ?) UNION ALL (SELECT `kind`, ? AS `total` FROM `archive`) LIMIT 5", q)
+	assert.Equal([]interface{}{1, 0}, p)
+}
+
+func TestBuilderMisuse(t *testing.T) {
+	assert := assert.New(t)
+	for _, b := range []*Builder{
+		Select(Column("id")).From(Table("user")).OrderBy(Ascending("id")).Where(IsEqual("a", 1)),
+		Select(Column("id")).From(Table("user")).Limit(1).OrderBy(Ascending("id")),
+		Select(Column("id")).From(Table("user")).Limit(1).Limit(2),
+		Select(Column("id")).From(Table("user")).Range(-1, 10),
+		Select(Column("id")).From(Table("user")).Where(nil),
+		Select(Column("id")).From(Table("user")).OrderBy(OrderDef{Name: "id"}),
+		Query(Column("id"), Table("user"), OrderDef{Name: "id", Direction: "; DROP TABLE user"}),
+		Query(ColumnDef{Name: "id", Func: "SLEEP"}, Table("user")),
+		Query(ColumnDef{Name: "id", Expr: "SLEEP"}, Table("user")),
+		Query(Column("id"), Table("user"), IsInSubquery("id", Subquery(ColumnDef{Name: "user_id", Func: "SLEEP"}, Table("repo")))),
+		Query(Column("id"), Table("user"), NotDef{OrGrouping(Exists(Subquery(Column("id"), Table("repo"), Limit(1), Limit(2))))}),
+		Query(Column("id"), Table("user"), Union(ColumnDef{Name: "id", Func: "SLEEP"}, Table("bot"))),
+		Query(Column("id"), Table("user"), InnerJoin(Table("repo"), On(ColumnDef{Table: "repo", Name: "user_id", Func: "SLEEP"}, TableColumn("user", "id")))),
+		Query(Column("id"), Table("user"), Having(Join("a", "b"), JoinDef{A: Column("a"), B: ColumnDef{Name: "b", Func: "SLEEP"}})),
+		Select(Column("id")).Join(InnerJoin(Table("repo"))),
+		Select().From(Table("user")),
+		Select(Column("id")).From(Table("user")).Union(Select().From(Table("bot"))),
+		Query(Column("id"), Table("user"), "id = 1"),
+		Query(Column("id"), Table("user"), Range(0, 10), Limit(1)),
+	} {
+		q, p, err := b.Build()
+		assert.True(errors.Is(err, ErrInvalidQuery), "%v", err)
+		assert.Equal("", q)
+		assert.Nil(p)
+	}
+	_, _, err := Select(Column("id")).From(Table("user")).Limit(1).Limit(2).Build()
+	assert.Equal("orm: invalid query: LIMIT can only be set once", err.Error())
+	_, _, err = Select(Column("id")).From(Table("user")).Limit(1).Where(IsEqual("a", 1)).Build()
+	assert.Equal("orm: invalid query: WHERE can't be added after LIMIT", err.Error())
+}
+
+func TestBuilderQuery(t *testing.T) {
+	assert := assert.New(t)
+	// the components are written in SQL order regardless of the order they're passed
+	q, p, err := Query(Range(0, 10), Descending("id"), IsEqual("a", 1), Table("user"), Column("id")).Build()
+	assert.Nil(err)
+	assert.Equal("SELECT `id` FROM `user` WHERE `a` = ? ORDER BY `id` DESC LIMIT 0,10", q)
+	assert.Equal([]interface{}{1}, p)
+
+	// BuildQuery ignores the misuse and keeps the first limit
+	q, _ = BuildQuery(Column("id"), Table("user"), Limit(1), Limit(2))
+	assert.Equal("SELECT `id` FROM `user` LIMIT 1", q)
+}
+
+func TestBuilderCount(t *testing.T) {
+	assert := assert.New(t)
+	b := Select(Column("id"), Column("name")).From(Table("user")).Where(IsEqual("active", true)).OrderBy(Ascending("name")).Limit(10)
+	q, p, err := b.Count()
+	assert.Nil(err)
+	assert.Equal("SELECT COUNT(*) FROM `user` WHERE `active` = ?", q)
+	assert.Equal([]interface{}{true}, p)
+
+	// the builder is unchanged by Count
+	q, _, err = b.Build()
+	assert.Nil(err)
+	assert.Equal("SELECT `id`, `name` FROM `user` WHERE `active` = ? ORDER BY `name` ASC LIMIT 10", q)
+
+	q, p, err = Query(Table("user"), IsEqual("active", true)).Count()
+	assert.Nil(err)
+	assert.Equal("SELECT COUNT(*) FROM `user` WHERE `active` = ?", q)
+	assert.Equal([]interface{}{true}, p)
+
+	q, p, err = Select(Column("kind")).From(Table("event")).Where(IsEqual("a", 1)).GroupBy(GroupBy("kind")).OrderBy(Ascending("kind")).Count()
+	assert.Nil(err)
+	assert.Equal("SELECT COUNT(*) FROM (SELECT `kind` FROM `event` WHERE `a` = ? GROUP BY `kind`) AS `count`", q)
+	assert.Equal([]interface{}{1}, p)
+
+	_, _, err = Select(Column("id")).From(Table("user")).Limit(1).Limit(2).Count()
+	assert.True(errors.Is(err, ErrInvalidQuery))
+}
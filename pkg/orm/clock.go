@@ -0,0 +1,58 @@
+package orm
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	tspb "github.com/golang/protobuf/ptypes/timestamp"
+)
+
+// Clock is the source of the current time used for the managed timestamp columns such as created_at and updated_at
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is a function which can be used as a Clock
+type ClockFunc func() time.Time
+
+// Now returns the result of calling the function
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// FixedClock returns a Clock which always returns t which is useful for deterministic tests
+func FixedClock(t time.Time) Clock {
+	return ClockFunc(func() time.Time {
+		return t
+	})
+}
+
+var (
+	clock     Clock = ClockFunc(time.Now)
+	clockLock sync.RWMutex
+)
+
+// SetClock replaces the clock used by the generated code. passing nil restores the system clock
+func SetClock(c Clock) {
+	if c == nil {
+		c = ClockFunc(time.Now)
+	}
+	clockLock.Lock()
+	clock = c
+	clockLock.Unlock()
+}
+
+// Now returns the current time from the clock
+func Now() time.Time {
+	clockLock.RLock()
+	c := clock
+	clockLock.RUnlock()
+	return c.Now()
+}
+
+// ToTimestampTime returns the proto Timestamp for the time passed
+func ToTimestampTime(t time.Time) *tspb.Timestamp {
+	ts, _ := ptypes.TimestampProto(t)
+	return ts
+}
@@ -0,0 +1,21 @@
+package orm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClock(t *testing.T) {
+	assert := assert.New(t)
+	fixed := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+	SetClock(FixedClock(fixed))
+	defer SetClock(nil)
+	assert.Equal(fixed, Now())
+	ts := ToTimestampNow()
+	assert.Equal(fixed.Unix(), ts.Seconds)
+	assert.Equal(ts, ToTimestampTime(fixed))
+	SetClock(nil)
+	assert.NotEqual(fixed, Now())
+}
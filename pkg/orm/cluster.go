@@ -0,0 +1,181 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Replica is a read replica of a Cluster. reads are spread over the healthy replicas in proportion to their Weight
+type Replica struct {
+	DB     *sql.DB
+	Weight int
+}
+
+type replica struct {
+	Replica
+	healthy int32
+}
+
+// Cluster is an Executor which sends writes, transactions and locking reads to the primary and the other reads to a replica. it can be passed as the executor of the generated functions
+type Cluster struct {
+	primary  *sql.DB
+	replicas []*replica
+}
+
+var (
+	_ Executor   = (*Cluster)(nil)
+	_ TxBeginner = (*Cluster)(nil)
+)
+
+// NewCluster returns a cluster for the primary and replicas. the replicas are considered healthy until a health check fails and a weight less than 1 is treated as 1
+func NewCluster(primary *sql.DB, replicas ...Replica) *Cluster {
+	c := &Cluster{primary: primary}
+	for _, r := range replicas {
+		if r.Weight < 1 {
+			r.Weight = 1
+		}
+		c.replicas = append(c.replicas, &replica{r, 1})
+	}
+	return c
+}
+
+// Primary returns the primary database
+func (c *Cluster) Primary() *sql.DB {
+	return c.primary
+}
+
+type clusterContext struct {
+	primary bool
+	written int32
+}
+
+type clusterContextKey struct{}
+
+// WithPrimary returns a context in which all the queries made through a Cluster go to the primary
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, clusterContextKey{}, &clusterContext{primary: true})
+}
+
+// WithReadYourWrites returns a context in which the reads made through a Cluster go to the primary once a write has been made with the context so that they see the write
+func WithReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, clusterContextKey{}, &clusterContext{})
+}
+
+func isReplicaQuery(query string) bool {
+	q := strings.ToUpper(strings.TrimSpace(query))
+	if strings.HasPrefix(q, "SELECT") == false {
+		return false
+	}
+	return strings.Contains(q, " FOR UPDATE") == false && strings.Contains(q, " FOR SHARE") == false && strings.Contains(q, " LOCK IN SHARE MODE") == false
+}
+
+// reader returns the database which should run the query
+func (c *Cluster) reader(ctx context.Context, query string) *sql.DB {
+	if cc, ok := ctx.Value(clusterContextKey{}).(*clusterContext); ok {
+		if cc.primary || atomic.LoadInt32(&cc.written) == 1 {
+			return c.primary
+		}
+	}
+	if isReplicaQuery(query) == false {
+		return c.primary
+	}
+	var total int
+	for _, r := range c.replicas {
+		if atomic.LoadInt32(&r.healthy) == 1 {
+			total += r.Weight
+		}
+	}
+	if total == 0 {
+		return c.primary
+	}
+	n := rand.Intn(total)
+	for _, r := range c.replicas {
+		if atomic.LoadInt32(&r.healthy) == 1 {
+			if n < r.Weight {
+				return r.DB
+			}
+			n -= r.Weight
+		}
+	}
+	return c.primary
+}
+
+func (c *Cluster) writer(ctx context.Context) *sql.DB {
+	if cc, ok := ctx.Value(clusterContextKey{}).(*clusterContext); ok {
+		atomic.StoreInt32(&cc.written, 1)
+	}
+	return c.primary
+}
+
+// ExecContext runs the statement on the primary
+func (c *Cluster) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.writer(ctx).ExecContext(ctx, query, args...)
+}
+
+// QueryContext runs a read on a healthy replica and any other query on the primary
+func (c *Cluster) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return c.reader(ctx, query).QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext runs a read on a healthy replica and any other query on the primary
+func (c *Cluster) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return c.reader(ctx, query).QueryRowContext(ctx, query, args...)
+}
+
+// BeginTx begins a transaction on the primary so that every query of the transaction runs on the primary
+func (c *Cluster) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return c.writer(ctx).BeginTx(ctx, opts)
+}
+
+// CheckHealth pings each replica and only sends reads to the ones which respond
+func (c *Cluster) CheckHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, r := range c.replicas {
+		wg.Add(1)
+		go func(r *replica) {
+			defer wg.Done()
+			if r.DB.PingContext(ctx) == nil {
+				atomic.StoreInt32(&r.healthy, 1)
+			} else {
+				atomic.StoreInt32(&r.healthy, 0)
+			}
+		}(r)
+	}
+	wg.Wait()
+}
+
+// StartHealthChecks checks the health of the replicas every interval until the returned function is called
+func (c *Cluster) StartHealthChecks(interval time.Duration) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				check, done := context.WithTimeout(ctx, interval)
+				c.CheckHealth(check)
+				done()
+			}
+		}
+	}()
+	return cancel
+}
+
+// Close closes the primary and the replicas
+func (c *Cluster) Close() error {
+	err := c.primary.Close()
+	for _, r := range c.replicas {
+		if rerr := r.DB.Close(); err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
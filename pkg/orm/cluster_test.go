@@ -0,0 +1,75 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// downDriver is a database which can't be connected to
+type downDriver struct{}
+
+func (d downDriver) Open(name string) (driver.Conn, error) {
+	return nil, errors.New("down")
+}
+
+func init() {
+	sql.Register("ormdown", downDriver{})
+}
+
+func TestClusterRouting(t *testing.T) {
+	assert := assert.New(t)
+	primary, _ := sql.Open("ormtx", "")
+	replica, _ := sql.Open("ormtx", "")
+	c := NewCluster(primary, Replica{DB: replica})
+	defer c.Close()
+	ctx := context.Background()
+	assert.Equal(primary, c.Primary())
+	assert.Equal(replica, c.reader(ctx, "SELECT `id` FROM `a`"))
+	assert.Equal(primary, c.reader(ctx, "SELECT `id` FROM `a` FOR UPDATE"))
+	assert.Equal(primary, c.reader(ctx, "UPDATE `a` SET `b` = 1"))
+	assert.Equal(primary, c.reader(WithPrimary(ctx), "SELECT `id` FROM `a`"))
+
+	sticky := WithReadYourWrites(ctx)
+	assert.Equal(replica, c.reader(sticky, "SELECT `id` FROM `a`"))
+	_, err := c.ExecContext(sticky, "UPDATE `a` SET `b` = 1")
+	assert.Nil(err)
+	assert.Equal(primary, c.reader(sticky, "SELECT `id` FROM `a`"))
+	assert.Equal(replica, c.reader(ctx, "SELECT `id` FROM `a`"))
+}
+
+func TestClusterHealth(t *testing.T) {
+	assert := assert.New(t)
+	primary, _ := sql.Open("ormtx", "")
+	up, _ := sql.Open("ormtx", "")
+	down, _ := sql.Open("ormdown", "")
+	c := NewCluster(primary, Replica{DB: down, Weight: 100}, Replica{DB: up})
+	defer c.Close()
+	ctx := context.Background()
+	c.CheckHealth(ctx)
+	for i := 0; i < 20; i++ {
+		assert.Equal(up, c.reader(ctx, "SELECT 1"))
+	}
+
+	c = NewCluster(primary, Replica{DB: down})
+	c.CheckHealth(ctx)
+	assert.Equal(primary, c.reader(ctx, "SELECT 1"))
+}
+
+func TestClusterWithTx(t *testing.T) {
+	assert := assert.New(t)
+	primary := openTxDB(t)
+	replica, _ := sql.Open("ormdown", "")
+	c := NewCluster(primary, Replica{DB: replica})
+	defer c.Close()
+	err := WithTx(context.Background(), c, nil, func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE a")
+		return err
+	})
+	assert.Nil(err)
+	assert.Equal([]string{"BEGIN", "UPDATE a", "COMMIT"}, testTxDriver.log)
+}
@@ -0,0 +1,38 @@
+package orm
+
+//go:generate go run gencolumns.go
+
+// Col is a column of a table which builds the conditions and orders which don't depend on its type. the typed columns in columns_gen.go embed it and it's used as is for JSON and geometry columns
+type Col struct {
+	Name string
+}
+
+// Column returns the column to select
+func (c Col) Column() ColumnDef {
+	return Column(c.Name)
+}
+
+// IsNull returns a condition which is true when the column is NULL
+func (c Col) IsNull() ConditionDef {
+	return IsNull(c.Name)
+}
+
+// IsNotNull returns a condition which is true when the column isn't NULL
+func (c Col) IsNotNull() ConditionDef {
+	return IsNotNull(c.Name)
+}
+
+// Asc returns an ascending order by the column
+func (c Col) Asc() OrderDef {
+	return Ascending(c.Name)
+}
+
+// Desc returns a descending order by the column
+func (c Col) Desc() OrderDef {
+	return Descending(c.Name)
+}
+
+// SetNull returns an assignment of NULL to the column
+func (c Col) SetNull() AssignmentDef {
+	return Set(c.Name, nil)
+}
@@ -0,0 +1,370 @@
+// Code generated by gencolumns.go. DO NOT EDIT.
+
+package orm
+
+import "time"
+
+// StringColumn is a string column whose conditions only accept a string
+type StringColumn struct {
+	Col
+}
+
+// Eq returns a condition which is true when the column is equal to the value
+func (c StringColumn) Eq(value string) ConditionDef {
+	return IsEqual(c.Name, value)
+}
+
+// NotEq returns a condition which is true when the column isn't equal to the value
+func (c StringColumn) NotEq(value string) ConditionDef {
+	return IsNotEqual(c.Name, value)
+}
+
+// Gt returns a condition which is true when the column is greater than the value
+func (c StringColumn) Gt(value string) ConditionDef {
+	return IsGreaterThan(c.Name, value)
+}
+
+// Gte returns a condition which is true when the column is greater than or equal to the value
+func (c StringColumn) Gte(value string) ConditionDef {
+	return IsGreaterThanEqual(c.Name, value)
+}
+
+// Lt returns a condition which is true when the column is less than the value
+func (c StringColumn) Lt(value string) ConditionDef {
+	return IsLessThan(c.Name, value)
+}
+
+// Lte returns a condition which is true when the column is less than or equal to the value
+func (c StringColumn) Lte(value string) ConditionDef {
+	return IsLessThanEqual(c.Name, value)
+}
+
+// Between returns a condition which is true when the column is between the values inclusively
+func (c StringColumn) Between(from, to string) ConditionDef {
+	return IsBetween(c.Name, from, to)
+}
+
+// In returns a condition which is true when the column is one of the values. no values never matches
+func (c StringColumn) In(values ...string) ConditionDef {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return IsIn(c.Name, result)
+}
+
+// NotIn returns a condition which is true when the column isn't any of the values. no values always matches
+func (c StringColumn) NotIn(values ...string) ConditionDef {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return IsNotIn(c.Name, result)
+}
+
+// Like returns a condition which is true when the column matches the pattern
+func (c StringColumn) Like(pattern string) ConditionDef {
+	return IsLike(c.Name, pattern)
+}
+
+// NotLike returns a condition which is true when the column doesn't match the pattern
+func (c StringColumn) NotLike(pattern string) ConditionDef {
+	return IsNotLike(c.Name, pattern)
+}
+
+// Set returns an assignment of the value to the column
+func (c StringColumn) Set(value string) AssignmentDef {
+	return Set(c.Name, value)
+}
+
+// Int32Column is an int32 column whose conditions only accept a int32
+type Int32Column struct {
+	Col
+}
+
+// Eq returns a condition which is true when the column is equal to the value
+func (c Int32Column) Eq(value int32) ConditionDef {
+	return IsEqual(c.Name, value)
+}
+
+// NotEq returns a condition which is true when the column isn't equal to the value
+func (c Int32Column) NotEq(value int32) ConditionDef {
+	return IsNotEqual(c.Name, value)
+}
+
+// Gt returns a condition which is true when the column is greater than the value
+func (c Int32Column) Gt(value int32) ConditionDef {
+	return IsGreaterThan(c.Name, value)
+}
+
+// Gte returns a condition which is true when the column is greater than or equal to the value
+func (c Int32Column) Gte(value int32) ConditionDef {
+	return IsGreaterThanEqual(c.Name, value)
+}
+
+// Lt returns a condition which is true when the column is less than the value
+func (c Int32Column) Lt(value int32) ConditionDef {
+	return IsLessThan(c.Name, value)
+}
+
+// Lte returns a condition which is true when the column is less than or equal to the value
+func (c Int32Column) Lte(value int32) ConditionDef {
+	return IsLessThanEqual(c.Name, value)
+}
+
+// Between returns a condition which is true when the column is between the values inclusively
+func (c Int32Column) Between(from, to int32) ConditionDef {
+	return IsBetween(c.Name, from, to)
+}
+
+// In returns a condition which is true when the column is one of the values. no values never matches
+func (c Int32Column) In(values ...int32) ConditionDef {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return IsIn(c.Name, result)
+}
+
+// NotIn returns a condition which is true when the column isn't any of the values. no values always matches
+func (c Int32Column) NotIn(values ...int32) ConditionDef {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return IsNotIn(c.Name, result)
+}
+
+// Set returns an assignment of the value to the column
+func (c Int32Column) Set(value int32) AssignmentDef {
+	return Set(c.Name, value)
+}
+
+// Int64Column is an int64 column whose conditions only accept a int64
+type Int64Column struct {
+	Col
+}
+
+// Eq returns a condition which is true when the column is equal to the value
+func (c Int64Column) Eq(value int64) ConditionDef {
+	return IsEqual(c.Name, value)
+}
+
+// NotEq returns a condition which is true when the column isn't equal to the value
+func (c Int64Column) NotEq(value int64) ConditionDef {
+	return IsNotEqual(c.Name, value)
+}
+
+// Gt returns a condition which is true when the column is greater than the value
+func (c Int64Column) Gt(value int64) ConditionDef {
+	return IsGreaterThan(c.Name, value)
+}
+
+// Gte returns a condition which is true when the column is greater than or equal to the value
+func (c Int64Column) Gte(value int64) ConditionDef {
+	return IsGreaterThanEqual(c.Name, value)
+}
+
+// Lt returns a condition which is true when the column is less than the value
+func (c Int64Column) Lt(value int64) ConditionDef {
+	return IsLessThan(c.Name, value)
+}
+
+// Lte returns a condition which is true when the column is less than or equal to the value
+func (c Int64Column) Lte(value int64) ConditionDef {
+	return IsLessThanEqual(c.Name, value)
+}
+
+// Between returns a condition which is true when the column is between the values inclusively
+func (c Int64Column) Between(from, to int64) ConditionDef {
+	return IsBetween(c.Name, from, to)
+}
+
+// In returns a condition which is true when the column is one of the values. no values never matches
+func (c Int64Column) In(values ...int64) ConditionDef {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return IsIn(c.Name, result)
+}
+
+// NotIn returns a condition which is true when the column isn't any of the values. no values always matches
+func (c Int64Column) NotIn(values ...int64) ConditionDef {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return IsNotIn(c.Name, result)
+}
+
+// Set returns an assignment of the value to the column
+func (c Int64Column) Set(value int64) AssignmentDef {
+	return Set(c.Name, value)
+}
+
+// Float32Column is a float column whose conditions only accept a float32
+type Float32Column struct {
+	Col
+}
+
+// Eq returns a condition which is true when the column is equal to the value
+func (c Float32Column) Eq(value float32) ConditionDef {
+	return IsEqual(c.Name, value)
+}
+
+// NotEq returns a condition which is true when the column isn't equal to the value
+func (c Float32Column) NotEq(value float32) ConditionDef {
+	return IsNotEqual(c.Name, value)
+}
+
+// Gt returns a condition which is true when the column is greater than the value
+func (c Float32Column) Gt(value float32) ConditionDef {
+	return IsGreaterThan(c.Name, value)
+}
+
+// Gte returns a condition which is true when the column is greater than or equal to the value
+func (c Float32Column) Gte(value float32) ConditionDef {
+	return IsGreaterThanEqual(c.Name, value)
+}
+
+// Lt returns a condition which is true when the column is less than the value
+func (c Float32Column) Lt(value float32) ConditionDef {
+	return IsLessThan(c.Name, value)
+}
+
+// Lte returns a condition which is true when the column is less than or equal to the value
+func (c Float32Column) Lte(value float32) ConditionDef {
+	return IsLessThanEqual(c.Name, value)
+}
+
+// Between returns a condition which is true when the column is between the values inclusively
+func (c Float32Column) Between(from, to float32) ConditionDef {
+	return IsBetween(c.Name, from, to)
+}
+
+// In returns a condition which is true when the column is one of the values. no values never matches
+func (c Float32Column) In(values ...float32) ConditionDef {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return IsIn(c.Name, result)
+}
+
+// NotIn returns a condition which is true when the column isn't any of the values. no values always matches
+func (c Float32Column) NotIn(values ...float32) ConditionDef {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return IsNotIn(c.Name, result)
+}
+
+// Set returns an assignment of the value to the column
+func (c Float32Column) Set(value float32) AssignmentDef {
+	return Set(c.Name, value)
+}
+
+// TimeColumn is a date or timestamp column whose conditions only accept a time.Time
+type TimeColumn struct {
+	Col
+}
+
+// Eq returns a condition which is true when the column is equal to the value
+func (c TimeColumn) Eq(value time.Time) ConditionDef {
+	return IsEqual(c.Name, value)
+}
+
+// NotEq returns a condition which is true when the column isn't equal to the value
+func (c TimeColumn) NotEq(value time.Time) ConditionDef {
+	return IsNotEqual(c.Name, value)
+}
+
+// Gt returns a condition which is true when the column is greater than the value
+func (c TimeColumn) Gt(value time.Time) ConditionDef {
+	return IsGreaterThan(c.Name, value)
+}
+
+// Gte returns a condition which is true when the column is greater than or equal to the value
+func (c TimeColumn) Gte(value time.Time) ConditionDef {
+	return IsGreaterThanEqual(c.Name, value)
+}
+
+// Lt returns a condition which is true when the column is less than the value
+func (c TimeColumn) Lt(value time.Time) ConditionDef {
+	return IsLessThan(c.Name, value)
+}
+
+// Lte returns a condition which is true when the column is less than or equal to the value
+func (c TimeColumn) Lte(value time.Time) ConditionDef {
+	return IsLessThanEqual(c.Name, value)
+}
+
+// Between returns a condition which is true when the column is between the values inclusively
+func (c TimeColumn) Between(from, to time.Time) ConditionDef {
+	return IsBetween(c.Name, from, to)
+}
+
+// In returns a condition which is true when the column is one of the values. no values never matches
+func (c TimeColumn) In(values ...time.Time) ConditionDef {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return IsIn(c.Name, result)
+}
+
+// NotIn returns a condition which is true when the column isn't any of the values. no values always matches
+func (c TimeColumn) NotIn(values ...time.Time) ConditionDef {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return IsNotIn(c.Name, result)
+}
+
+// Set returns an assignment of the value to the column
+func (c TimeColumn) Set(value time.Time) AssignmentDef {
+	return Set(c.Name, value)
+}
+
+// BoolColumn is a bool column whose conditions only accept a bool
+type BoolColumn struct {
+	Col
+}
+
+// Eq returns a condition which is true when the column is equal to the value
+func (c BoolColumn) Eq(value bool) ConditionDef {
+	return IsEqual(c.Name, value)
+}
+
+// NotEq returns a condition which is true when the column isn't equal to the value
+func (c BoolColumn) NotEq(value bool) ConditionDef {
+	return IsNotEqual(c.Name, value)
+}
+
+// Set returns an assignment of the value to the column
+func (c BoolColumn) Set(value bool) AssignmentDef {
+	return Set(c.Name, value)
+}
+
+// BytesColumn is a binary column whose conditions only accept a []byte
+type BytesColumn struct {
+	Col
+}
+
+// Eq returns a condition which is true when the column is equal to the value
+func (c BytesColumn) Eq(value []byte) ConditionDef {
+	return IsEqual(c.Name, value)
+}
+
+// NotEq returns a condition which is true when the column isn't equal to the value
+func (c BytesColumn) NotEq(value []byte) ConditionDef {
+	return IsNotEqual(c.Name, value)
+}
+
+// Set returns an assignment of the value to the column
+func (c BytesColumn) Set(value []byte) AssignmentDef {
+	return Set(c.Name, value)
+}
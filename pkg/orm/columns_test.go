@@ -0,0 +1,38 @@
+package orm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColumns(t *testing.T) {
+	assert := assert.New(t)
+	id := Int64Column{Col{"id"}}
+	name := StringColumn{Col{"name"}}
+	active := BoolColumn{Col{"active"}}
+	created := TimeColumn{Col{"created_at"}}
+	when := time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	q, p := BuildQuery(id.Column(), name.Column(), Table("user"),
+		id.In(1, 2, 3),
+		name.Like(LikePrefix("bo")),
+		active.Eq(true),
+		created.Between(when, when.Add(time.Hour)),
+		OrGrouping(name.IsNull(), name.NotEq("x")),
+		id.Gte(10),
+		name.Desc(),
+		id.Asc(),
+	)
+	assert.Equal("SELECT `id`, `name` FROM `user` WHERE `id` IN (?,?,?) AND `name` LIKE ? AND `active` = ? AND `created_at` BETWEEN ? AND ? AND (`name` IS NULL OR `name` != ?) AND `id` >= ? ORDER BY `name` DESC ,`id` ASC", q)
+	assert.Equal([]interface{}{int64(1), int64(2), int64(3), "bo%", true, when, when.Add(time.Hour), "x", int64(10)}, p)
+
+	q, p = BuildUpdate("user", []AssignmentDef{name.Set("bob"), created.SetNull(), Col{"data"}.SetNull()}, id.Eq(1), id.NotIn(2))
+	assert.Equal("UPDATE `user` SET `name` = ?, `created_at` = ?, `data` = ? WHERE `id` = ? AND `id` NOT IN (?)", q)
+	assert.Equal([]interface{}{"bob", nil, nil, int64(1), int64(2)}, p)
+
+	q, p = BuildQuery(Table("user"), id.In(), name.NotIn())
+	assert.Equal("FROM `user` WHERE (1=0) AND (1=1)", q)
+	assert.Len(p, 0)
+}
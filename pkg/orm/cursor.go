@@ -0,0 +1,284 @@
+package orm
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	cursorKey     []byte
+	cursorKeyLock sync.RWMutex
+)
+
+func init() {
+	SetCursorKey(nil)
+}
+
+// SetCursorKey sets the secret used to sign page cursors. passing nil uses a random key which means cursors are only valid for the life of the process
+func SetCursorKey(key []byte) {
+	if key == nil {
+		key = make([]byte, 32)
+		randomBytes(key)
+	}
+	cursorKeyLock.Lock()
+	cursorKey = key
+	cursorKeyLock.Unlock()
+}
+
+func signCursor(payload []byte) []byte {
+	cursorKeyLock.RLock()
+	mac := hmac.New(sha256.New, cursorKey)
+	cursorKeyLock.RUnlock()
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// PageInfo has the cursors of the pages around a keyset page. a cursor is empty when there is no page in that direction
+type PageInfo struct {
+	Next     string
+	Previous string
+}
+
+// Cursor is the decoded position of a keyset page. Values are the order column and primary key values of the record the page continues from and are nil for the first page
+type Cursor struct {
+	Order    OrderDef
+	Values   []interface{}
+	Backward bool
+}
+
+type cursorValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v,omitempty"`
+}
+
+type cursorPayload struct {
+	Name      string        `json:"n"`
+	Direction Direction     `json:"d"`
+	Backward  bool          `json:"b,omitempty"`
+	Values    []cursorValue `json:"v"`
+}
+
+func encodeCursorValue(v interface{}) (cursorValue, error) {
+	dv, err := driver.DefaultParameterConverter.ConvertValue(v)
+	if err != nil {
+		return cursorValue{}, err
+	}
+	switch t := dv.(type) {
+	case nil:
+		return cursorValue{Type: "n"}, nil
+	case int64:
+		return cursorValue{Type: "i", Value: strconv.FormatInt(t, 10)}, nil
+	case float64:
+		return cursorValue{Type: "f", Value: strconv.FormatFloat(t, 'g', -1, 64)}, nil
+	case bool:
+		return cursorValue{Type: "b", Value: strconv.FormatBool(t)}, nil
+	case string:
+		return cursorValue{Type: "s", Value: t}, nil
+	case []byte:
+		return cursorValue{Type: "x", Value: base64.RawURLEncoding.EncodeToString(t)}, nil
+	case time.Time:
+		return cursorValue{Type: "t", Value: t.Format(time.RFC3339Nano)}, nil
+	}
+	return cursorValue{}, fmt.Errorf("orm: unsupported cursor value %T", dv)
+}
+
+func decodeCursorValue(v cursorValue) (interface{}, error) {
+	switch v.Type {
+	case "n":
+		return nil, nil
+	case "i":
+		return strconv.ParseInt(v.Value, 10, 64)
+	case "f":
+		return strconv.ParseFloat(v.Value, 64)
+	case "b":
+		return strconv.ParseBool(v.Value)
+	case "s":
+		return v.Value, nil
+	case "x":
+		return base64.RawURLEncoding.DecodeString(v.Value)
+	case "t":
+		return time.Parse(time.RFC3339Nano, v.Value)
+	}
+	return nil, ErrInvalidCursor
+}
+
+func normalizeOrder(order OrderDef) OrderDef {
+	if order.Direction != DirectionDescending {
+		order.Direction = DirectionAscending
+	}
+	return order
+}
+
+// EncodeCursor returns the signed opaque token for the cursor
+func EncodeCursor(c *Cursor) (string, error) {
+	order := normalizeOrder(c.Order)
+	payload := cursorPayload{
+		Name:      order.Name,
+		Direction: order.Direction,
+		Backward:  c.Backward,
+		Values:    make([]cursorValue, 0, len(c.Values)),
+	}
+	for _, v := range c.Values {
+		cv, err := encodeCursorValue(v)
+		if err != nil {
+			return "", err
+		}
+		payload.Values = append(payload.Values, cv)
+	}
+	buf, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf) + "." + base64.RawURLEncoding.EncodeToString(signCursor(buf)), nil
+}
+
+// DecodeCursor returns the cursor for a token created by EncodeCursor or the cursor of the first page when the token is empty. the token must have been created for the same order
+func DecodeCursor(token string, order OrderDef) (*Cursor, error) {
+	order = normalizeOrder(order)
+	if token == "" {
+		return &Cursor{Order: order}, nil
+	}
+	i := strings.IndexByte(token, '.')
+	if i < 0 {
+		return nil, ErrInvalidCursor
+	}
+	buf, err := base64.RawURLEncoding.DecodeString(token[0:i])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(token[i+1:])
+	if err != nil || hmac.Equal(sig, signCursor(buf)) == false {
+		return nil, ErrInvalidCursor
+	}
+	var payload cursorPayload
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	if err := dec.Decode(&payload); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if payload.Name != order.Name || payload.Direction != order.Direction || len(payload.Values) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	c := &Cursor{
+		Order:    order,
+		Backward: payload.Backward,
+		Values:   make([]interface{}, 0, len(payload.Values)),
+	}
+	for _, cv := range payload.Values {
+		v, err := decodeCursorValue(cv)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		c.Values = append(c.Values, v)
+	}
+	return c, nil
+}
+
+// KeysetDef is a row comparison such as (`a`,`b`) > (?,?) which selects the records after a page cursor
+type KeysetDef struct {
+	Names    []string
+	Operator Operator
+	Values   []interface{}
+}
+
+func (k KeysetDef) String() string {
+	if len(k.Names) == 1 {
+		return QuoteIdentifier(k.Names[0]) + " " + string(k.Operator) + " ?"
+	}
+	names := make([]string, len(k.Names))
+	for i, name := range k.Names {
+		names[i] = QuoteIdentifier(name)
+	}
+	return "(" + strings.Join(names, ",") + ") " + string(k.Operator) + " (" + makeInExpr(k.Values) + ")"
+}
+
+// AddValue appends the values of the comparison to the parameters
+func (k KeysetDef) AddValue(params []interface{}) []interface{} {
+	return append(params, k.Values...)
+}
+
+// Keyset returns a row comparison of the columns to the values
+func Keyset(names []string, operator Operator, values []interface{}) KeysetDef {
+	return KeysetDef{names, operator, values}
+}
+
+// KeysetColumns returns the order column followed by the primary key unless the page is ordered by the primary key
+func KeysetColumns(order OrderDef, pk string) []string {
+	if order.Name == pk {
+		return []string{pk}
+	}
+	return []string{order.Name, pk}
+}
+
+// KeysetParams returns the condition, order and limit query components which select the page after the cursor. one more record than the page size is selected to detect if there is another page
+func KeysetParams(c *Cursor, pk string, size int32) ([]interface{}, error) {
+	if size <= 0 {
+		return nil, ErrInvalidPageSize
+	}
+	names := KeysetColumns(c.Order, pk)
+	direction := c.Order.Direction
+	if c.Backward {
+		if direction == DirectionAscending {
+			direction = DirectionDescending
+		} else {
+			direction = DirectionAscending
+		}
+	}
+	params := make([]interface{}, 0)
+	if c.Values != nil {
+		if len(c.Values) != len(names) {
+			return nil, ErrInvalidCursor
+		}
+		operator := OperatorGreaterThan
+		if direction == DirectionDescending {
+			operator = OperatorLessThan
+		}
+		params = append(params, Keyset(names, operator, c.Values))
+	}
+	for _, name := range names {
+		params = append(params, OrderDef{name, direction})
+	}
+	return append(params, Limit(size+1)), nil
+}
+
+// CheckPageParams returns a QueryError if the params of a page have an order or limit which would clash with the ones from KeysetParams
+func CheckPageParams(params []interface{}) error {
+	for _, param := range params {
+		switch param.(type) {
+		case OrderDef, LimitDef, RangeDef:
+			{
+				return &QueryError{fmt.Sprintf("%T can't be used in the params of a page since the page is ordered and limited by the cursor", param)}
+			}
+		}
+	}
+	return nil
+}
+
+// NewPageInfo returns the cursors around a page given the cursor the page was selected with, whether there are more records past the page and the keyset values of the first and last records in the page
+func NewPageInfo(c *Cursor, more bool, first []interface{}, last []interface{}) (PageInfo, error) {
+	var info PageInfo
+	var err error
+	if first == nil || last == nil {
+		return info, nil
+	}
+	// a backward page was reached from the page after it so there is always a next page
+	if more || c.Backward {
+		if info.Next, err = EncodeCursor(&Cursor{Order: c.Order, Values: last}); err != nil {
+			return info, err
+		}
+	}
+	if (c.Backward && more) || (c.Backward == false && c.Values != nil) {
+		if info.Previous, err = EncodeCursor(&Cursor{Order: c.Order, Values: first, Backward: true}); err != nil {
+			return info, err
+		}
+	}
+	return info, nil
+}
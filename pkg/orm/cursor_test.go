@@ -0,0 +1,107 @@
+package orm
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorEncodeDecode(t *testing.T) {
+	assert := assert.New(t)
+	ts := time.Date(2018, 1, 2, 3, 4, 5, 6, time.UTC)
+	token, err := EncodeCursor(&Cursor{
+		Order:  Descending("created_at"),
+		Values: []interface{}{ts, sql.NullInt64{Int64: 10, Valid: true}, "a", int32(1), []byte("b"), nil},
+	})
+	assert.Nil(err)
+	c, err := DecodeCursor(token, Descending("created_at"))
+	assert.Nil(err)
+	assert.False(c.Backward)
+	assert.Equal([]interface{}{ts, int64(10), "a", int64(1), []byte("b"), nil}, c.Values)
+
+	_, err = DecodeCursor(token, Ascending("created_at"))
+	assert.Equal(ErrInvalidCursor, err)
+	_, err = DecodeCursor(token+"x", Descending("created_at"))
+	assert.Equal(ErrInvalidCursor, err)
+	_, err = DecodeCursor("garbage", Descending("created_at"))
+	assert.Equal(ErrInvalidCursor, err)
+
+	SetCursorKey([]byte("secret"))
+	defer SetCursorKey(nil)
+	_, err = DecodeCursor(token, Descending("created_at"))
+	assert.Equal(ErrInvalidCursor, err)
+
+	c, err = DecodeCursor("", OrderDef{Name: "id"})
+	assert.Nil(err)
+	assert.Nil(c.Values)
+	assert.Equal(DirectionAscending, c.Order.Direction)
+}
+
+func TestKeysetParams(t *testing.T) {
+	assert := assert.New(t)
+	c, _ := DecodeCursor("", Ascending("name"))
+	params, err := KeysetParams(c, "id", 10)
+	assert.Nil(err)
+	q, p := BuildQuery(append([]interface{}{Column("id"), Table("t")}, params...)...)
+	assert.Equal("SELECT `id` FROM `t` ORDER BY `name` ASC ,`id` ASC LIMIT 11", q)
+	assert.Len(p, 0)
+
+	c = &Cursor{Order: Ascending("name"), Values: []interface{}{"a", 1}, Backward: true}
+	params, err = KeysetParams(c, "id", 10)
+	assert.Nil(err)
+	q, p = BuildQuery(append([]interface{}{Column("id"), Table("t"), IsEqual("x", 1)}, params...)...)
+	assert.Equal("SELECT `id` FROM `t` WHERE `x` = ? AND (`name`,`id`) < (?,?) ORDER BY `name` DESC ,`id` DESC LIMIT 11", q)
+	assert.Equal([]interface{}{1, "a", 1}, p)
+
+	c = &Cursor{Order: Descending("id"), Values: []interface{}{5}}
+	params, err = KeysetParams(c, "id", 10)
+	assert.Nil(err)
+	q, _ = BuildQuery(append([]interface{}{Column("id"), Table("t")}, params...)...)
+	assert.Equal("SELECT `id` FROM `t` WHERE `id` < ? ORDER BY `id` DESC LIMIT 11", q)
+
+	_, err = KeysetParams(c, "id", 0)
+	assert.Equal(ErrInvalidPageSize, err)
+	_, err = KeysetParams(c, "name", 10)
+	assert.Equal(ErrInvalidCursor, err)
+}
+
+func TestCheckPageParams(t *testing.T) {
+	assert := assert.New(t)
+	assert.Nil(CheckPageParams([]interface{}{IsEqual("x", 1), Column("id")}))
+	assert.True(errors.Is(CheckPageParams([]interface{}{Limit(5)}), ErrInvalidQuery))
+	assert.True(errors.Is(CheckPageParams([]interface{}{Range(0, 5)}), ErrInvalidQuery))
+	assert.True(errors.Is(CheckPageParams([]interface{}{IsEqual("x", 1), Descending("x")}), ErrInvalidQuery))
+}
+
+func TestNewPageInfo(t *testing.T) {
+	assert := assert.New(t)
+	first, last := []interface{}{1}, []interface{}{2}
+	c, _ := DecodeCursor("", Ascending("id"))
+	info, err := NewPageInfo(c, true, first, last)
+	assert.Nil(err)
+	assert.Equal("", info.Previous)
+	next, err := DecodeCursor(info.Next, Ascending("id"))
+	assert.Nil(err)
+	assert.False(next.Backward)
+	assert.Equal([]interface{}{int64(2)}, next.Values)
+
+	info, err = NewPageInfo(next, false, first, last)
+	assert.Nil(err)
+	assert.Equal("", info.Next)
+	prev, err := DecodeCursor(info.Previous, Ascending("id"))
+	assert.Nil(err)
+	assert.True(prev.Backward)
+	assert.Equal([]interface{}{int64(1)}, prev.Values)
+
+	info, err = NewPageInfo(prev, false, first, last)
+	assert.Nil(err)
+	assert.Equal("", info.Previous)
+	assert.NotEqual("", info.Next)
+
+	info, err = NewPageInfo(prev, true, nil, nil)
+	assert.Nil(err)
+	assert.Equal(PageInfo{}, info)
+}
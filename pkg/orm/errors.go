@@ -0,0 +1,189 @@
+package orm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ErrConflict is returned by an optimistic update or delete when the record was changed or deleted since it was read
+var ErrConflict = errors.New("orm: record was changed or deleted since it was read")
+
+// ErrStop can be returned by the callback of a generated ForEach function to stop the iteration without an error
+var ErrStop = errors.New("orm: stop iteration")
+
+// ErrInvalidCursor is returned when a page cursor is malformed, wasn't signed with the cursor key or was created for a different order
+var ErrInvalidCursor = errors.New("orm: invalid page cursor")
+
+// ErrInvalidPageSize is returned when the size of a page is not greater than zero
+var ErrInvalidPageSize = errors.New("orm: page size must be greater than zero")
+
+// ErrInvalidFilter matches a FilterError with errors.Is
+var ErrInvalidFilter = errors.New("orm: invalid filter")
+
+// ErrInvalidQuery matches a QueryError with errors.Is
+var ErrInvalidQuery = errors.New("orm: invalid query")
+
+// ErrNotFound matches a sql.ErrNoRows wrapped by WrapError. the generated find methods don't return it since they report a missing record by returning false or nil
+var ErrNotFound = errors.New("orm: record not found")
+
+// ErrDuplicateKey matches a DuplicateKeyError with errors.Is
+var ErrDuplicateKey = errors.New("orm: duplicate key")
+
+// ErrForeignKey matches a ForeignKeyError with errors.Is
+var ErrForeignKey = errors.New("orm: foreign key constraint failed")
+
+// ErrDataTruncated is matched by a value which is too long or out of range for its column
+var ErrDataTruncated = errors.New("orm: data truncated")
+
+// ErrTransient is matched by a deadlock or lock wait timeout which can be retried (see WithTx)
+var ErrTransient = errors.New("orm: transient error")
+
+// MySQL error numbers which are classified by WrapError
+const (
+	ErrorNumberDuplicateKey    = 1062
+	ErrorNumberLockWaitTimeout = 1205
+	ErrorNumberDeadlock        = 1213
+	ErrorNumberOutOfRange      = 1264
+	ErrorNumberDataTruncated   = 1265
+	ErrorNumberDataTooLong     = 1406
+	ErrorNumberRowIsReferenced = 1451
+	ErrorNumberNoReferencedRow = 1452
+)
+
+// DuplicateKeyError is returned when a write violates a primary or unique key
+type DuplicateKeyError struct {
+	// Index is the name of the key which was violated such as PRIMARY without the table name MySQL 8 qualifies it with
+	Index string
+	Err   error
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return fmt.Sprintf("orm: duplicate entry for key `%s`: %v", e.Index, e.Err)
+}
+
+// Is returns true for ErrDuplicateKey
+func (e *DuplicateKeyError) Is(target error) bool {
+	return target == ErrDuplicateKey
+}
+
+// Unwrap returns the driver error
+func (e *DuplicateKeyError) Unwrap() error {
+	return e.Err
+}
+
+// ForeignKeyError is returned when a write violates a foreign key constraint
+type ForeignKeyError struct {
+	// Constraint is the name of the foreign key constraint which failed
+	Constraint string
+	// Referenced is true when the record couldn't be deleted or updated because another record references it
+	Referenced bool
+	Err        error
+}
+
+func (e *ForeignKeyError) Error() string {
+	return fmt.Sprintf("orm: foreign key constraint `%s` failed: %v", e.Constraint, e.Err)
+}
+
+// Is returns true for ErrForeignKey
+func (e *ForeignKeyError) Is(target error) bool {
+	return target == ErrForeignKey
+}
+
+// Unwrap returns the driver error
+func (e *ForeignKeyError) Unwrap() error {
+	return e.Err
+}
+
+// classifiedError wraps a driver error so that it matches one of the sentinel errors
+type classifiedError struct {
+	sentinel error
+	err      error
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Is(target error) bool {
+	return target == e.sentinel
+}
+
+func (e *classifiedError) Unwrap() error {
+	return e.err
+}
+
+// quoted returns the text between the first open quote after the marker and the matching close quote
+func quoted(message string, marker string, open string, close string) string {
+	i := strings.Index(message, marker)
+	if i < 0 {
+		return ""
+	}
+	s := message[i+len(marker):]
+	i = strings.Index(s, open)
+	if i < 0 {
+		return ""
+	}
+	s = s[i+len(open):]
+	if i = strings.Index(s, close); i >= 0 {
+		return s[0:i]
+	}
+	return ""
+}
+
+// WrapError classifies a driver error so that it can be matched with errors.Is and errors.As against the orm errors. other errors are returned unchanged
+func WrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if err == sql.ErrNoRows {
+		return &classifiedError{ErrNotFound, err}
+	}
+	var merr *mysql.MySQLError
+	if errors.As(err, &merr) == false {
+		return err
+	}
+	switch merr.Number {
+	case ErrorNumberDuplicateKey:
+		{
+			// Duplicate entry 'value' for key 'index' which is 'table.index' in MySQL 8
+			index := strings.Trim(merr.Message[strings.LastIndex(merr.Message, " ")+1:], "'")
+			if i := strings.LastIndex(index, "."); i >= 0 {
+				index = index[i+1:]
+			}
+			return &DuplicateKeyError{index, err}
+		}
+	case ErrorNumberNoReferencedRow, ErrorNumberRowIsReferenced:
+		{
+			// Cannot add or update a child row: a foreign key constraint fails (`db`.`table`, CONSTRAINT `name` FOREIGN KEY ...)
+			referenced := merr.Number == ErrorNumberRowIsReferenced
+			return &ForeignKeyError{quoted(merr.Message, "CONSTRAINT", "`", "`"), referenced, err}
+		}
+	case ErrorNumberOutOfRange, ErrorNumberDataTruncated, ErrorNumberDataTooLong:
+		{
+			return &classifiedError{ErrDataTruncated, err}
+		}
+	case ErrorNumberDeadlock, ErrorNumberLockWaitTimeout:
+		{
+			return &classifiedError{ErrTransient, err}
+		}
+	}
+	return err
+}
+
+// QueryError is returned by a Builder which was used incorrectly such as a clause added out of order
+type QueryError struct {
+	Reason string
+}
+
+func (e *QueryError) Error() string {
+	return "orm: invalid query: " + e.Reason
+}
+
+// Is returns true for ErrInvalidQuery
+func (e *QueryError) Is(target error) bool {
+	return target == ErrInvalidQuery
+}
@@ -0,0 +1,60 @@
+package orm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapErrorDuplicateKey(t *testing.T) {
+	assert := assert.New(t)
+	merr := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a' for key 'idx_name'"}
+	err := WrapError(merr)
+	assert.True(errors.Is(err, ErrDuplicateKey))
+	var derr *DuplicateKeyError
+	assert.True(errors.As(err, &derr))
+	assert.Equal("idx_name", derr.Index)
+	var driver *mysql.MySQLError
+	assert.True(errors.As(err, &driver))
+	assert.Equal(merr, driver)
+
+	err = WrapError(fmt.Errorf("insert: %w", merr))
+	assert.True(errors.Is(err, ErrDuplicateKey))
+
+	// mysql 8 qualifies the key with the table name
+	err = WrapError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1' for key 'user.PRIMARY'"})
+	assert.True(errors.As(err, &derr))
+	assert.Equal("PRIMARY", derr.Index)
+}
+
+func TestWrapErrorForeignKey(t *testing.T) {
+	assert := assert.New(t)
+	err := WrapError(&mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails (`db`.`b`, CONSTRAINT `fk_b_a` FOREIGN KEY (`a_id`) REFERENCES `a` (`id`))"})
+	assert.True(errors.Is(err, ErrForeignKey))
+	var ferr *ForeignKeyError
+	assert.True(errors.As(err, &ferr))
+	assert.Equal("fk_b_a", ferr.Constraint)
+	assert.False(ferr.Referenced)
+
+	err = WrapError(&mysql.MySQLError{Number: 1451, Message: "Cannot delete or update a parent row: a foreign key constraint fails (`db`.`b`, CONSTRAINT `fk_b_a` FOREIGN KEY (`a_id`) REFERENCES `a` (`id`))"})
+	assert.True(errors.As(err, &ferr))
+	assert.True(ferr.Referenced)
+}
+
+func TestWrapErrorClassified(t *testing.T) {
+	assert := assert.New(t)
+	assert.Nil(WrapError(nil))
+	assert.True(errors.Is(WrapError(sql.ErrNoRows), ErrNotFound))
+	assert.True(errors.Is(WrapError(sql.ErrNoRows), sql.ErrNoRows))
+	assert.True(errors.Is(WrapError(&mysql.MySQLError{Number: 1406}), ErrDataTruncated))
+	assert.True(errors.Is(WrapError(&mysql.MySQLError{Number: 1213}), ErrTransient))
+	assert.True(errors.Is(WrapError(&mysql.MySQLError{Number: 1205}), ErrTransient))
+	assert.False(errors.Is(WrapError(&mysql.MySQLError{Number: 1064}), ErrTransient))
+	boom := errors.New("boom")
+	assert.Equal(boom, WrapError(boom))
+	assert.Equal(ErrConflict, WrapError(ErrConflict))
+}
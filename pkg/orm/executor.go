@@ -0,0 +1,13 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Executor is the interface implemented by *sql.DB, *sql.Tx and *sql.Conn which is used to run queries
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
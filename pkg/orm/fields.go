@@ -0,0 +1,27 @@
+package orm
+
+import "fmt"
+
+// FieldMask is the set of fields for a partial update. it's implemented by google.protobuf.FieldMask so patch requests can be passed straight through
+type FieldMask interface {
+	GetPaths() []string
+}
+
+// FieldError is returned by a partial update when a field can't be updated
+type FieldError struct {
+	Table  string
+	Field  string
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("orm: field `%s` of `%s` %s", e.Field, e.Table, e.Reason)
+}
+
+// Paths is a FieldMask for a list of field names
+type Paths []string
+
+// GetPaths returns the field names
+func (p Paths) GetPaths() []string {
+	return p
+}
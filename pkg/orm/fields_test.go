@@ -0,0 +1,19 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldMask(t *testing.T) {
+	assert := assert.New(t)
+	var mask FieldMask = Paths{"a", "b"}
+	assert.Equal([]string{"a", "b"}, mask.GetPaths())
+}
+
+func TestFieldError(t *testing.T) {
+	assert := assert.New(t)
+	err := &FieldError{"user", "foo", "is not a column"}
+	assert.Equal("orm: field `foo` of `user` is not a column", err.Error())
+}
@@ -0,0 +1,460 @@
+package orm
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FilterType is the type a filter value is coerced to before it's used in a condition
+type FilterType int
+
+const (
+	FilterString FilterType = iota
+	FilterInt
+	FilterFloat
+	FilterBool
+	FilterTime
+)
+
+// FilterOperator is an operator which can be used in a filter. it's the suffix of a URL parameter such as age_gt
+type FilterOperator string
+
+const (
+	FilterEqual            FilterOperator = "eq"
+	FilterNotEqual         FilterOperator = "ne"
+	FilterGreaterThan      FilterOperator = "gt"
+	FilterGreaterThanEqual FilterOperator = "gte"
+	FilterLessThan         FilterOperator = "lt"
+	FilterLessThanEqual    FilterOperator = "lte"
+	FilterIn               FilterOperator = "in"
+	FilterNotIn            FilterOperator = "nin"
+	FilterLike             FilterOperator = "like"
+	FilterNull             FilterOperator = "null"
+)
+
+// the operators which are allowed for each kind of column
+var (
+	FilterOperatorsEqual   = []FilterOperator{FilterEqual, FilterNotEqual, FilterIn, FilterNotIn}
+	FilterOperatorsOrdered = []FilterOperator{FilterEqual, FilterNotEqual, FilterIn, FilterNotIn, FilterGreaterThan, FilterGreaterThanEqual, FilterLessThan, FilterLessThanEqual}
+	FilterOperatorsString  = []FilterOperator{FilterEqual, FilterNotEqual, FilterIn, FilterNotIn, FilterGreaterThan, FilterGreaterThanEqual, FilterLessThan, FilterLessThanEqual, FilterLike}
+)
+
+// the page size used when a FilterSchema doesn't set one
+const (
+	DefaultFilterLimit int32 = 20
+	MaxFilterLimit     int32 = 100
+)
+
+// the URL parameters which aren't filters on a column
+const (
+	FilterParamFilter = "filter"
+	FilterParamSort   = "sort"
+	FilterParamLimit  = "limit"
+	FilterParamOffset = "offset"
+)
+
+// FilterField is a column which can be filtered and sorted on from an API request
+type FilterField struct {
+	Name      string
+	Type      FilterType
+	Operators []FilterOperator
+	// Values are the only values which are accepted such as the values of an enum. any value is accepted if it's empty
+	Values []string
+	// Nullable allows the null operator
+	Nullable bool
+	Sortable bool
+}
+
+// allows returns true if the operator can be used with the field
+func (f FilterField) allows(op FilterOperator) bool {
+	if op == FilterNull {
+		return f.Nullable
+	}
+	for _, o := range f.Operators {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// coerce converts a value from a request to the type of the field
+func (f FilterField) coerce(value string) (interface{}, error) {
+	if len(f.Values) > 0 {
+		for _, v := range f.Values {
+			if v == value {
+				return value, nil
+			}
+		}
+		return nil, fmt.Errorf("must be one of %s", strings.Join(f.Values, ", "))
+	}
+	switch f.Type {
+	case FilterInt:
+		{
+			v, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("must be an integer")
+			}
+			return v, nil
+		}
+	case FilterFloat:
+		{
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("must be a number")
+			}
+			return v, nil
+		}
+	case FilterBool:
+		{
+			v, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("must be true or false")
+			}
+			return v, nil
+		}
+	case FilterTime:
+		{
+			for _, layout := range []string{time.RFC3339Nano, "2006-01-02"} {
+				if v, err := time.Parse(layout, value); err == nil {
+					return v.UTC(), nil
+				}
+			}
+			return nil, fmt.Errorf("must be a RFC 3339 time or a YYYY-MM-DD date")
+		}
+	}
+	return value, nil
+}
+
+// FilterError is returned when a filter, sort or page parameter of a request is invalid
+type FilterError struct {
+	Param  string
+	Reason string
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("orm: invalid parameter `%s`: %s", e.Param, e.Reason)
+}
+
+// Is returns true for ErrInvalidFilter
+func (e *FilterError) Is(target error) bool {
+	return target == ErrInvalidFilter
+}
+
+// FilterSchema is the allow-list of the columns and operators which can be used in the filters of an API request. a schema is generated for each table
+type FilterSchema struct {
+	Fields []FilterField
+	// DefaultLimit is the page size when the request doesn't have a limit. DefaultFilterLimit is used if it's zero
+	DefaultLimit int32
+	// MaxLimit is the largest page size a request can have. MaxFilterLimit is used if it's zero
+	MaxLimit int32
+}
+
+// Field returns the field for the column name and false if it's not in the schema
+func (s FilterSchema) Field(name string) (FilterField, bool) {
+	for _, f := range s.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return FilterField{}, false
+}
+
+// Only returns a copy of the schema with just the columns named
+func (s FilterSchema) Only(names ...string) FilterSchema {
+	allowed := NewAllowlist(names...)
+	fields := make([]FilterField, 0)
+	for _, f := range s.Fields {
+		if allowed.Allowed(f.Name) {
+			fields = append(fields, f)
+		}
+	}
+	s.Fields = fields
+	return s
+}
+
+// Without returns a copy of the schema without the columns named
+func (s FilterSchema) Without(names ...string) FilterSchema {
+	removed := NewAllowlist(names...)
+	fields := make([]FilterField, 0)
+	for _, f := range s.Fields {
+		if removed.Allowed(f.Name) == false {
+			fields = append(fields, f)
+		}
+	}
+	s.Fields = fields
+	return s
+}
+
+// Allowlist returns an allow-list of the columns in the schema
+func (s FilterSchema) Allowlist() Allowlist {
+	a := make(Allowlist)
+	for _, f := range s.Fields {
+		a[f.Name] = true
+	}
+	return a
+}
+
+// Parse returns the conditions, orders and LIMIT for the URL parameters of a request such as ?status=open&age_gt=3&sort=-day&limit=50. a column parameter is compared for equality or with the operator in its suffix and repeating it or using the in suffix with a comma separated list matches any of the values. the filter parameter has conditions in the grammar of ParseFilter. parameters which aren't in the schema return a FilterError unless they're ignored
+func (s FilterSchema) Parse(values url.Values, ignore ...string) ([]interface{}, error) {
+	ignored := NewAllowlist(ignore...)
+	components := make([]interface{}, 0)
+	// the parameters are sorted so that the order of the conditions is stable
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ignored.Allowed(name) {
+			continue
+		}
+		switch name {
+		case FilterParamSort, FilterParamLimit, FilterParamOffset:
+			{
+				continue
+			}
+		case FilterParamFilter:
+			{
+				for _, expr := range values[name] {
+					conditions, err := s.ParseFilter(expr)
+					if err != nil {
+						return nil, err
+					}
+					components = append(components, conditions...)
+				}
+				continue
+			}
+		}
+		field, op, ok := s.param(name)
+		if ok == false {
+			return nil, &FilterError{name, "is not a filter"}
+		}
+		var raw []string
+		if op == FilterIn || op == FilterNotIn {
+			for _, v := range values[name] {
+				raw = append(raw, strings.Split(v, ",")...)
+			}
+		} else {
+			raw = values[name]
+		}
+		if len(raw) > 1 {
+			// repeated parameters match any of the values
+			switch op {
+			case FilterEqual:
+				op = FilterIn
+			case FilterNotEqual:
+				op = FilterNotIn
+			}
+		}
+		condition, err := s.condition(name, field, op, raw)
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, condition)
+	}
+	orders, err := s.sort(values[FilterParamSort])
+	if err != nil {
+		return nil, err
+	}
+	components = append(components, orders...)
+	limit, err := s.limit(values.Get(FilterParamLimit), values.Get(FilterParamOffset))
+	if err != nil {
+		return nil, err
+	}
+	return append(components, limit), nil
+}
+
+// filterOperators are the operators of the compact grammar. the two character operators are first so that they're matched before their prefix
+var filterOperators = []struct {
+	token string
+	op    FilterOperator
+}{
+	{">=", FilterGreaterThanEqual},
+	{"<=", FilterLessThanEqual},
+	{"!=", FilterNotEqual},
+	{">", FilterGreaterThan},
+	{"<", FilterLessThan},
+	{"=", FilterEqual},
+	{"~", FilterLike},
+}
+
+// ParseFilter returns the conditions for a compact filter of conditions separated by commas such as status=open|closed,age>3,name~bob. the operators are = != > >= < <= and ~ which matches values containing the text. values separated by | match any of them with = or none of them with != and a value with a comma or | must use a column parameter instead
+func (s FilterSchema) ParseFilter(expr string) ([]interface{}, error) {
+	conditions := make([]interface{}, 0)
+	for _, term := range strings.Split(expr, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		end := strings.IndexAny(term, "=!<>~")
+		if end <= 0 {
+			return nil, &FilterError{FilterParamFilter, "`" + term + "` is not a condition"}
+		}
+		name := term[0:end]
+		rest := term[end:]
+		var op FilterOperator
+		for _, o := range filterOperators {
+			if strings.HasPrefix(rest, o.token) {
+				op = o.op
+				rest = rest[len(o.token):]
+				break
+			}
+		}
+		if op == "" {
+			return nil, &FilterError{FilterParamFilter, "`" + term + "` is not a condition"}
+		}
+		field, ok := s.Field(name)
+		if ok == false {
+			return nil, &FilterError{FilterParamFilter, "`" + name + "` is not a filter"}
+		}
+		raw := []string{rest}
+		if op == FilterEqual || op == FilterNotEqual {
+			raw = strings.Split(rest, "|")
+			if len(raw) > 1 {
+				if op == FilterEqual {
+					op = FilterIn
+				} else {
+					op = FilterNotIn
+				}
+			}
+		}
+		condition, err := s.condition(FilterParamFilter, field, op, raw)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions, nil
+}
+
+// param returns the field and operator for a URL parameter such as age_gt
+func (s FilterSchema) param(name string) (FilterField, FilterOperator, bool) {
+	if f, ok := s.Field(name); ok {
+		return f, FilterEqual, true
+	}
+	i := strings.LastIndex(name, "_")
+	if i <= 0 {
+		return FilterField{}, "", false
+	}
+	f, ok := s.Field(name[0:i])
+	return f, FilterOperator(name[i+1:]), ok
+}
+
+// condition returns the condition for the operator after checking it's allowed and coercing the values
+func (s FilterSchema) condition(param string, field FilterField, op FilterOperator, raw []string) (ConditionDef, error) {
+	if field.allows(op) == false {
+		return ConditionDef{}, &FilterError{param, "operator " + string(op) + " can't be used with `" + field.Name + "`"}
+	}
+	if op == FilterNull {
+		if len(raw) != 1 {
+			return ConditionDef{}, &FilterError{param, "must be true or false"}
+		}
+		isnull, err := strconv.ParseBool(raw[0])
+		if err != nil {
+			return ConditionDef{}, &FilterError{param, "must be true or false"}
+		}
+		if isnull {
+			return IsNull(field.Name), nil
+		}
+		return IsNotNull(field.Name), nil
+	}
+	if op == FilterLike {
+		if len(raw) != 1 || raw[0] == "" {
+			return ConditionDef{}, &FilterError{param, "must have a single value"}
+		}
+		return IsLike(field.Name, LikeContains(raw[0])), nil
+	}
+	values := make([]interface{}, len(raw))
+	for i, r := range raw {
+		v, err := field.coerce(r)
+		if err != nil {
+			return ConditionDef{}, &FilterError{param, err.Error()}
+		}
+		values[i] = v
+	}
+	if op != FilterIn && op != FilterNotIn && len(values) != 1 {
+		return ConditionDef{}, &FilterError{param, "must have a single value"}
+	}
+	switch op {
+	case FilterEqual:
+		return IsEqual(field.Name, values[0]), nil
+	case FilterNotEqual:
+		return IsNotEqual(field.Name, values[0]), nil
+	case FilterGreaterThan:
+		return IsGreaterThan(field.Name, values[0]), nil
+	case FilterGreaterThanEqual:
+		return IsGreaterThanEqual(field.Name, values[0]), nil
+	case FilterLessThan:
+		return IsLessThan(field.Name, values[0]), nil
+	case FilterLessThanEqual:
+		return IsLessThanEqual(field.Name, values[0]), nil
+	case FilterIn:
+		return IsIn(field.Name, values), nil
+	}
+	return IsNotIn(field.Name, values), nil
+}
+
+// sort returns the orders for the sort parameter which is a comma separated list of columns with a - prefix for descending
+func (s FilterSchema) sort(params []string) ([]interface{}, error) {
+	orders := make([]interface{}, 0)
+	seen := make(Allowlist)
+	for _, param := range params {
+		for _, name := range strings.Split(param, ",") {
+			name = strings.TrimSpace(name)
+			desc := strings.HasPrefix(name, "-")
+			name = strings.TrimLeft(name, "+-")
+			field, ok := s.Field(name)
+			if ok == false || field.Sortable == false {
+				return nil, &FilterError{FilterParamSort, "can't sort by `" + name + "`"}
+			}
+			if seen.Allowed(name) {
+				return nil, &FilterError{FilterParamSort, "`" + name + "` is used more than once"}
+			}
+			seen[name] = true
+			if desc {
+				orders = append(orders, Descending(name))
+			} else {
+				orders = append(orders, Ascending(name))
+			}
+		}
+	}
+	return orders, nil
+}
+
+// limit returns the LIMIT for the limit and offset parameters which defaults to the default limit of the schema
+func (s FilterSchema) limit(limit, offset string) (interface{}, error) {
+	max := s.MaxLimit
+	if max <= 0 {
+		max = MaxFilterLimit
+	}
+	size := s.DefaultLimit
+	if size <= 0 {
+		size = DefaultFilterLimit
+	}
+	if size > max {
+		size = max
+	}
+	if limit != "" {
+		v, err := strconv.ParseInt(limit, 10, 32)
+		if err != nil || v <= 0 {
+			return nil, &FilterError{FilterParamLimit, "must be a positive integer"}
+		}
+		if int32(v) > max {
+			return nil, &FilterError{FilterParamLimit, fmt.Sprintf("can't be more than %d", max)}
+		}
+		size = int32(v)
+	}
+	if offset != "" {
+		v, err := strconv.ParseInt(offset, 10, 32)
+		if err != nil || v < 0 {
+			return nil, &FilterError{FilterParamOffset, "must be zero or a positive integer"}
+		}
+		return Range(int32(v), size), nil
+	}
+	return Limit(size), nil
+}
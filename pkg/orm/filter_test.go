@@ -0,0 +1,111 @@
+package orm
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testFilterSchema = FilterSchema{
+	Fields: []FilterField{
+		{Name: "id", Type: FilterInt, Operators: FilterOperatorsOrdered, Sortable: true},
+		{Name: "status", Type: FilterString, Operators: FilterOperatorsEqual, Values: []string{"open", "closed"}, Sortable: true},
+		{Name: "name", Type: FilterString, Operators: FilterOperatorsString, Sortable: true},
+		{Name: "age", Type: FilterInt, Operators: FilterOperatorsOrdered},
+		{Name: "score", Type: FilterFloat, Operators: FilterOperatorsOrdered},
+		{Name: "active", Type: FilterBool, Operators: FilterOperatorsEqual},
+		{Name: "day", Type: FilterTime, Operators: FilterOperatorsOrdered, Nullable: true, Sortable: true},
+	},
+	MaxLimit: 50,
+}
+
+func parseFilterQuery(s FilterSchema, query string, ignore ...string) (string, []interface{}, error) {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return "", nil, err
+	}
+	components, err := s.Parse(values, ignore...)
+	if err != nil {
+		return "", nil, err
+	}
+	q, p := BuildQuery(components...)
+	return q, p, nil
+}
+
+func TestFilterParse(t *testing.T) {
+	assert := assert.New(t)
+	q, p, err := parseFilterQuery(testFilterSchema, "status=open&age_gt=3&sort=-day,name&limit=50")
+	assert.Nil(err)
+	assert.Equal("WHERE `age` > ? AND `status` = ? ORDER BY `day` DESC ,`name` ASC LIMIT 50", q)
+	assert.Equal([]interface{}{int64(3), "open"}, p)
+
+	q, p, err = parseFilterQuery(testFilterSchema, "status=open&status=closed&id_nin=1,2&id_in=3&id_in=4,5&offset=40")
+	assert.Nil(err)
+	assert.Equal("WHERE `id` IN (?,?,?) AND `id` NOT IN (?,?) AND `status` IN (?,?) LIMIT 40,20", q)
+	assert.Equal([]interface{}{int64(3), int64(4), int64(5), int64(1), int64(2), "open", "closed"}, p)
+
+	q, p, err = parseFilterQuery(testFilterSchema, "day_null=true&day_gte=2017-06-01&score_lt=1.5&active=false&name_like=50%25_off")
+	assert.Nil(err)
+	assert.Equal("WHERE `active` = ? AND `day` >= ? AND `day` IS NULL AND `name` LIKE ? AND `score` < ? LIMIT 20", q)
+	assert.Equal([]interface{}{false, time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC), "%50\\%\\_off%", 1.5}, p)
+
+	q, p, err = parseFilterQuery(testFilterSchema, "filter=status!=open|closed,age>=18,name~bo,day<2017-06-01T12:00:00Z&page=2", "page")
+	assert.Nil(err)
+	assert.Equal("WHERE `status` NOT IN (?,?) AND `age` >= ? AND `name` LIKE ? AND `day` < ? LIMIT 20", q)
+	assert.Equal([]interface{}{"open", "closed", int64(18), "%bo%", time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)}, p)
+}
+
+func TestFilterParseErrors(t *testing.T) {
+	assert := assert.New(t)
+	for query, reason := range map[string]string{
+		"password=x":               "orm: invalid parameter `password`: is not a filter",
+		"age_like=3":               "orm: invalid parameter `age_like`: operator like can't be used with `age`",
+		"age_foo=3":                "orm: invalid parameter `age_foo`: operator foo can't be used with `age`",
+		"age=old":                  "orm: invalid parameter `age`: must be an integer",
+		"age_gt=1&age_gt=2":        "orm: invalid parameter `age_gt`: must have a single value",
+		"status=pending":           "orm: invalid parameter `status`: must be one of open, closed",
+		"active=maybe":             "orm: invalid parameter `active`: must be true or false",
+		"day=yesterday":            "orm: invalid parameter `day`: must be a RFC 3339 time or a YYYY-MM-DD date",
+		"name_null=true":           "orm: invalid parameter `name_null`: operator null can't be used with `name`",
+		"sort=age":                 "orm: invalid parameter `sort`: can't sort by `age`",
+		"sort=id,-id":              "orm: invalid parameter `sort`: `id` is used more than once",
+		"sort=id`--":               "orm: invalid parameter `sort`: can't sort by `id`--`",
+		"limit=51":                 "orm: invalid parameter `limit`: can't be more than 50",
+		"limit=0":                  "orm: invalid parameter `limit`: must be a positive integer",
+		"offset=-1":                "orm: invalid parameter `offset`: must be zero or a positive integer",
+		"filter=age":               "orm: invalid parameter `filter`: `age` is not a condition",
+		"filter=password=x":        "orm: invalid parameter `filter`: `password` is not a filter",
+		"filter=name~":             "orm: invalid parameter `filter`: must have a single value",
+		"filter=id>1|2":            "orm: invalid parameter `filter`: must be an integer",
+		"filter=status=open,age<x": "orm: invalid parameter `filter`: must be an integer",
+	} {
+		_, _, err := parseFilterQuery(testFilterSchema, query)
+		if assert.NotNil(err, query) {
+			assert.True(errors.Is(err, ErrInvalidFilter), query)
+			assert.Equal(reason, err.Error(), query)
+		}
+	}
+}
+
+func TestFilterSchema(t *testing.T) {
+	assert := assert.New(t)
+	s := testFilterSchema.Only("id", "name", "age")
+	_, ok := s.Field("status")
+	assert.False(ok)
+	assert.Len(s.Fields, 3)
+	assert.Len(testFilterSchema.Fields, 7)
+	s = s.Without("age")
+	assert.Equal(NewAllowlist("id", "name"), s.Allowlist())
+
+	// the components pass the allow-list of the schema
+	components, err := testFilterSchema.Parse(url.Values{"id_gt": {"1"}, "sort": {"-name"}, "filter": {"status=open"}})
+	assert.Nil(err)
+	assert.Nil(testFilterSchema.Allowlist().Check(components...))
+
+	q, _, err := parseFilterQuery(FilterSchema{Fields: testFilterSchema.Fields, DefaultLimit: 500}, "")
+	assert.Nil(err)
+	assert.Equal("LIMIT 100", q)
+}
@@ -0,0 +1,130 @@
+//go:build ignore
+// +build ignore
+
+// gencolumns writes the typed columns in columns_gen.go from a single template. run it with go generate
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"text/template"
+)
+
+type typedColumn struct {
+	// Name is the name of the column type
+	Name string
+	// Kind describes the database columns of the type
+	Kind string
+	// Type is the Go type of the values
+	Type string
+	// Ordered adds the comparisons, BETWEEN and IN
+	Ordered bool
+	// Like adds LIKE and NOT LIKE
+	Like bool
+}
+
+var columns = []typedColumn{
+	{Name: "StringColumn", Kind: "a string", Type: "string", Ordered: true, Like: true},
+	{Name: "Int32Column", Kind: "an int32", Type: "int32", Ordered: true},
+	{Name: "Int64Column", Kind: "an int64", Type: "int64", Ordered: true},
+	{Name: "Float32Column", Kind: "a float", Type: "float32", Ordered: true},
+	{Name: "TimeColumn", Kind: "a date or timestamp", Type: "time.Time", Ordered: true},
+	{Name: "BoolColumn", Kind: "a bool", Type: "bool"},
+	{Name: "BytesColumn", Kind: "a binary", Type: "[]byte"},
+}
+
+var columnTemplate = template.Must(template.New("columns").Parse(`// Code generated by gencolumns.go. DO NOT EDIT.
+
+package orm
+
+import "time"
+{{range .}}
+// {{.Name}} is {{.Kind}} column whose conditions only accept a {{.Type}}
+type {{.Name}} struct {
+	Col
+}
+
+// Eq returns a condition which is true when the column is equal to the value
+func (c {{.Name}}) Eq(value {{.Type}}) ConditionDef {
+	return IsEqual(c.Name, value)
+}
+
+// NotEq returns a condition which is true when the column isn't equal to the value
+func (c {{.Name}}) NotEq(value {{.Type}}) ConditionDef {
+	return IsNotEqual(c.Name, value)
+}
+{{if .Ordered}}
+// Gt returns a condition which is true when the column is greater than the value
+func (c {{.Name}}) Gt(value {{.Type}}) ConditionDef {
+	return IsGreaterThan(c.Name, value)
+}
+
+// Gte returns a condition which is true when the column is greater than or equal to the value
+func (c {{.Name}}) Gte(value {{.Type}}) ConditionDef {
+	return IsGreaterThanEqual(c.Name, value)
+}
+
+// Lt returns a condition which is true when the column is less than the value
+func (c {{.Name}}) Lt(value {{.Type}}) ConditionDef {
+	return IsLessThan(c.Name, value)
+}
+
+// Lte returns a condition which is true when the column is less than or equal to the value
+func (c {{.Name}}) Lte(value {{.Type}}) ConditionDef {
+	return IsLessThanEqual(c.Name, value)
+}
+
+// Between returns a condition which is true when the column is between the values inclusively
+func (c {{.Name}}) Between(from, to {{.Type}}) ConditionDef {
+	return IsBetween(c.Name, from, to)
+}
+
+// In returns a condition which is true when the column is one of the values. no values never matches
+func (c {{.Name}}) In(values ...{{.Type}}) ConditionDef {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return IsIn(c.Name, result)
+}
+
+// NotIn returns a condition which is true when the column isn't any of the values. no values always matches
+func (c {{.Name}}) NotIn(values ...{{.Type}}) ConditionDef {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return IsNotIn(c.Name, result)
+}
+{{end}}{{if .Like}}
+// Like returns a condition which is true when the column matches the pattern
+func (c {{.Name}}) Like(pattern {{.Type}}) ConditionDef {
+	return IsLike(c.Name, pattern)
+}
+
+// NotLike returns a condition which is true when the column doesn't match the pattern
+func (c {{.Name}}) NotLike(pattern {{.Type}}) ConditionDef {
+	return IsNotLike(c.Name, pattern)
+}
+{{end}}
+// Set returns an assignment of the value to the column
+func (c {{.Name}}) Set(value {{.Type}}) AssignmentDef {
+	return Set(c.Name, value)
+}
+{{end}}`))
+
+func main() {
+	var buf bytes.Buffer
+	if err := columnTemplate.Execute(&buf, columns); err != nil {
+		log.Fatal(err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile("columns_gen.go", src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
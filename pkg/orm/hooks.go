@@ -0,0 +1,120 @@
+package orm
+
+import "context"
+
+// BeforeCreater is implemented by a model which needs to run logic before it's created. returning an error aborts the create
+type BeforeCreater interface {
+	BeforeCreate(ctx context.Context, db Executor) error
+}
+
+// AfterCreater is implemented by a model which needs to run logic after it's created
+type AfterCreater interface {
+	AfterCreate(ctx context.Context, db Executor) error
+}
+
+// BeforeUpdater is implemented by a model which needs to run logic before it's updated. returning an error aborts the update
+type BeforeUpdater interface {
+	BeforeUpdate(ctx context.Context, db Executor) error
+}
+
+// AfterUpdater is implemented by a model which needs to run logic after it's updated
+type AfterUpdater interface {
+	AfterUpdate(ctx context.Context, db Executor) error
+}
+
+// BeforeDeleter is implemented by a model which needs to run logic before it's deleted. returning an error aborts the delete
+type BeforeDeleter interface {
+	BeforeDelete(ctx context.Context, db Executor) error
+}
+
+// AfterDeleter is implemented by a model which needs to run logic after it's deleted. the primary key is still set when it's called
+type AfterDeleter interface {
+	AfterDelete(ctx context.Context, db Executor) error
+}
+
+// BeforeUpserter is implemented by a model which needs to run logic before it's upserted. returning an error aborts the upsert
+type BeforeUpserter interface {
+	BeforeUpsert(ctx context.Context, db Executor) error
+}
+
+// AfterUpserter is implemented by a model which needs to run logic after it's upserted
+type AfterUpserter interface {
+	AfterUpsert(ctx context.Context, db Executor) error
+}
+
+// AfterFinder is implemented by a model which needs to run logic after it's read from the database. a generated ForEach function calls it while its rows are open so it can't query db when that's a *sql.Tx or *sql.Conn
+type AfterFinder interface {
+	AfterFind(ctx context.Context, db Executor) error
+}
+
+// RunBeforeCreate calls BeforeCreate if the model implements BeforeCreater
+func RunBeforeCreate(ctx context.Context, db Executor, model interface{}) error {
+	if hook, ok := model.(BeforeCreater); ok {
+		return hook.BeforeCreate(ctx, db)
+	}
+	return nil
+}
+
+// RunAfterCreate calls AfterCreate if the model implements AfterCreater
+func RunAfterCreate(ctx context.Context, db Executor, model interface{}) error {
+	if hook, ok := model.(AfterCreater); ok {
+		return hook.AfterCreate(ctx, db)
+	}
+	return nil
+}
+
+// RunBeforeUpdate calls BeforeUpdate if the model implements BeforeUpdater
+func RunBeforeUpdate(ctx context.Context, db Executor, model interface{}) error {
+	if hook, ok := model.(BeforeUpdater); ok {
+		return hook.BeforeUpdate(ctx, db)
+	}
+	return nil
+}
+
+// RunAfterUpdate calls AfterUpdate if the model implements AfterUpdater
+func RunAfterUpdate(ctx context.Context, db Executor, model interface{}) error {
+	if hook, ok := model.(AfterUpdater); ok {
+		return hook.AfterUpdate(ctx, db)
+	}
+	return nil
+}
+
+// RunBeforeDelete calls BeforeDelete if the model implements BeforeDeleter
+func RunBeforeDelete(ctx context.Context, db Executor, model interface{}) error {
+	if hook, ok := model.(BeforeDeleter); ok {
+		return hook.BeforeDelete(ctx, db)
+	}
+	return nil
+}
+
+// RunAfterDelete calls AfterDelete if the model implements AfterDeleter
+func RunAfterDelete(ctx context.Context, db Executor, model interface{}) error {
+	if hook, ok := model.(AfterDeleter); ok {
+		return hook.AfterDelete(ctx, db)
+	}
+	return nil
+}
+
+// RunBeforeUpsert calls BeforeUpsert if the model implements BeforeUpserter
+func RunBeforeUpsert(ctx context.Context, db Executor, model interface{}) error {
+	if hook, ok := model.(BeforeUpserter); ok {
+		return hook.BeforeUpsert(ctx, db)
+	}
+	return nil
+}
+
+// RunAfterUpsert calls AfterUpsert if the model implements AfterUpserter
+func RunAfterUpsert(ctx context.Context, db Executor, model interface{}) error {
+	if hook, ok := model.(AfterUpserter); ok {
+		return hook.AfterUpsert(ctx, db)
+	}
+	return nil
+}
+
+// RunAfterFind calls AfterFind if the model implements AfterFinder
+func RunAfterFind(ctx context.Context, db Executor, model interface{}) error {
+	if hook, ok := model.(AfterFinder); ok {
+		return hook.AfterFind(ctx, db)
+	}
+	return nil
+}
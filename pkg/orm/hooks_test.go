@@ -0,0 +1,38 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type hookModel struct {
+	calls []string
+	err   error
+}
+
+func (m *hookModel) BeforeCreate(ctx context.Context, db Executor) error {
+	m.calls = append(m.calls, "BeforeCreate")
+	return m.err
+}
+
+func (m *hookModel) AfterFind(ctx context.Context, db Executor) error {
+	m.calls = append(m.calls, "AfterFind")
+	return nil
+}
+
+func TestHooks(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	m := &hookModel{}
+	assert.Nil(RunBeforeCreate(ctx, nil, m))
+	assert.Nil(RunAfterCreate(ctx, nil, m))
+	assert.Nil(RunBeforeUpdate(ctx, nil, m))
+	assert.Nil(RunAfterFind(ctx, nil, m))
+	assert.Equal([]string{"BeforeCreate", "AfterFind"}, m.calls)
+	m.err = errors.New("abort")
+	assert.Equal(m.err, RunBeforeCreate(ctx, nil, m))
+	assert.Nil(RunBeforeDelete(ctx, nil, struct{}{}))
+}
@@ -0,0 +1,217 @@
+package orm
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// IDStrategy is the strategy used to generate a primary key when a record is created
+type IDStrategy string
+
+const (
+	// IDStrategyNone leaves the primary key to the caller
+	IDStrategyNone IDStrategy = ""
+	// IDStrategyAutoIncrement lets the database assign the key from an auto increment column
+	IDStrategyAutoIncrement IDStrategy = "auto"
+	// IDStrategyUUID generates a random RFC 4122 version 4 UUID
+	IDStrategyUUID IDStrategy = "uuid"
+	// IDStrategyUUIDv7 generates a time ordered RFC 4122 version 7 UUID
+	IDStrategyUUIDv7 IDStrategy = "uuidv7"
+	// IDStrategyULID generates a time ordered ULID
+	IDStrategyULID IDStrategy = "ulid"
+	// IDStrategyHash generates a random 64 character hex string (see UUID) or 16 random bytes for a binary key
+	IDStrategyHash IDStrategy = "hash"
+)
+
+func randomBytes(buf []byte) {
+	if _, err := rand.Read(buf); err != nil {
+		panic("orm: unable to read random bytes: " + err.Error())
+	}
+}
+
+func formatUUID(b []byte) string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+	return string(buf[:])
+}
+
+// UUIDv4Bytes returns the 16 bytes of a random RFC 4122 version 4 UUID
+func UUIDv4Bytes() []byte {
+	b := make([]byte, 16)
+	randomBytes(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return b
+}
+
+// UUIDv4 returns a random RFC 4122 version 4 UUID string
+func UUIDv4() string {
+	return formatUUID(UUIDv4Bytes())
+}
+
+// UUIDv7Bytes returns the 16 bytes of a RFC 4122 version 7 UUID which sorts by creation time
+func UUIDv7Bytes() []byte {
+	b := make([]byte, 16)
+	randomBytes(b[6:])
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	b[0] = byte(ms >> 40)
+	b[1] = byte(ms >> 32)
+	b[2] = byte(ms >> 24)
+	b[3] = byte(ms >> 16)
+	b[4] = byte(ms >> 8)
+	b[5] = byte(ms)
+	b[6] = (b[6] & 0x0f) | 0x70
+	b[8] = (b[8] & 0x3f) | 0x80
+	return b
+}
+
+// UUIDv7 returns a RFC 4122 version 7 UUID string which sorts by creation time
+func UUIDv7() string {
+	return formatUUID(UUIDv7Bytes())
+}
+
+// UUIDToBytes returns the 16 bytes of a UUID string for storing in a binary(16) column
+func UUIDToBytes(uuid string) ([]byte, error) {
+	if len(uuid) != 36 || uuid[8] != '-' || uuid[13] != '-' || uuid[18] != '-' || uuid[23] != '-' {
+		return nil, fmt.Errorf("orm: invalid UUID %q", uuid)
+	}
+	b, err := hex.DecodeString(uuid[0:8] + uuid[9:13] + uuid[14:18] + uuid[19:23] + uuid[24:])
+	if err != nil {
+		return nil, fmt.Errorf("orm: invalid UUID %q", uuid)
+	}
+	return b, nil
+}
+
+// UUIDFromBytes returns the UUID string for the 16 bytes read from a binary(16) column
+func UUIDFromBytes(b []byte) (string, error) {
+	if len(b) != 16 {
+		return "", fmt.Errorf("orm: invalid UUID length %d", len(b))
+	}
+	return formatUUID(b), nil
+}
+
+// Crockford's base32 alphabet used by ULID
+const ulidAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULIDBytes returns the 16 bytes of a ULID (48 bit millisecond timestamp followed by 80 random bits)
+func ULIDBytes() []byte {
+	b := make([]byte, 16)
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	binary.BigEndian.PutUint16(b[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(b[2:6], uint32(ms))
+	randomBytes(b[6:])
+	return b
+}
+
+// ULID returns a 26 character ULID string which sorts by creation time
+func ULID() string {
+	s, _ := ULIDFromBytes(ULIDBytes())
+	return s
+}
+
+// ULIDFromBytes returns the ULID string for the 16 bytes read from a binary(16) column
+func ULIDFromBytes(b []byte) (string, error) {
+	if len(b) != 16 {
+		return "", fmt.Errorf("orm: invalid ULID length %d", len(b))
+	}
+	// 128 bits encoded 5 bits at a time, the first character only holds 3 bits
+	var buf [26]byte
+	hi := binary.BigEndian.Uint64(b[0:8])
+	lo := binary.BigEndian.Uint64(b[8:16])
+	for i := 25; i >= 0; i-- {
+		buf[i] = ulidAlphabet[lo&0x1f]
+		lo = (lo >> 5) | (hi << 59)
+		hi >>= 5
+	}
+	return string(buf[:]), nil
+}
+
+// ULIDToBytes returns the 16 bytes of a ULID string for storing in a binary(16) column
+func ULIDToBytes(ulid string) ([]byte, error) {
+	if len(ulid) != 26 || ulid[0] > '7' {
+		return nil, fmt.Errorf("orm: invalid ULID %q", ulid)
+	}
+	var hi, lo uint64
+	for _, c := range strings.ToUpper(ulid) {
+		v := strings.IndexRune(ulidAlphabet, c)
+		if v < 0 {
+			return nil, fmt.Errorf("orm: invalid ULID %q", ulid)
+		}
+		hi = (hi << 5) | (lo >> 59)
+		lo = (lo << 5) | uint64(v)
+	}
+	b := make([]byte, 16)
+	binary.BigEndian.PutUint64(b[0:8], hi)
+	binary.BigEndian.PutUint64(b[8:16], lo)
+	return b, nil
+}
+
+// NewID returns a new string key for the strategy. it returns an empty string for strategies where the database assigns the key
+func NewID(strategy IDStrategy) string {
+	switch strategy {
+	case IDStrategyUUID:
+		{
+			return UUIDv4()
+		}
+	case IDStrategyUUIDv7:
+		{
+			return UUIDv7()
+		}
+	case IDStrategyULID:
+		{
+			return ULID()
+		}
+	case IDStrategyHash:
+		{
+			return UUID()
+		}
+	}
+	return ""
+}
+
+// NewIDBytes returns a new binary key for the strategy. it returns nil for strategies where the database assigns the key
+func NewIDBytes(strategy IDStrategy) []byte {
+	switch strategy {
+	case IDStrategyUUID:
+		{
+			return UUIDv4Bytes()
+		}
+	case IDStrategyUUIDv7:
+		{
+			return UUIDv7Bytes()
+		}
+	case IDStrategyULID:
+		{
+			return ULIDBytes()
+		}
+	case IDStrategyHash:
+		{
+			b := make([]byte, 16)
+			randomBytes(b)
+			return b
+		}
+	}
+	return nil
+}
+
+// ToSQLAutoIncrement returns a NULL for a zero key so that the database will assign the next auto increment value
+func ToSQLAutoIncrement(v interface{}) sql.NullInt64 {
+	i := ToSQLInt64(v)
+	if i.Int64 == 0 {
+		return sql.NullInt64{}
+	}
+	return i
+}
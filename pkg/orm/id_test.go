@@ -0,0 +1,96 @@
+package orm
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUUIDv4(t *testing.T) {
+	assert := assert.New(t)
+	u := UUIDv4()
+	assert.Regexp(regexp.MustCompile("^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$"), u)
+	b, err := UUIDToBytes(u)
+	assert.Nil(err)
+	assert.Len(b, 16)
+	s, err := UUIDFromBytes(b)
+	assert.Nil(err)
+	assert.Equal(u, s)
+	_, err = UUIDToBytes("foo")
+	assert.NotNil(err)
+	_, err = UUIDFromBytes([]byte{1})
+	assert.NotNil(err)
+}
+
+func TestUUIDv7(t *testing.T) {
+	assert := assert.New(t)
+	u := UUIDv7()
+	assert.Regexp(regexp.MustCompile("^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$"), u)
+	b, err := UUIDToBytes(u)
+	assert.Nil(err)
+	assert.Equal(u, formatUUID(b))
+}
+
+func TestULID(t *testing.T) {
+	assert := assert.New(t)
+	u := ULID()
+	assert.Len(u, 26)
+	assert.Regexp(regexp.MustCompile("^[0-7][0-9A-HJKMNP-TV-Z]{25}$"), u)
+	b, err := ULIDToBytes(u)
+	assert.Nil(err)
+	s, err := ULIDFromBytes(b)
+	assert.Nil(err)
+	assert.Equal(u, s)
+	s, err = ULIDFromBytes(make([]byte, 16))
+	assert.Nil(err)
+	assert.Equal("00000000000000000000000000", s)
+	_, err = ULIDToBytes("80000000000000000000000000")
+	assert.NotNil(err)
+	_, err = ULIDToBytes("0000000000000000000000000U")
+	assert.NotNil(err)
+}
+
+func TestUUIDUnique(t *testing.T) {
+	assert := assert.New(t)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	ids := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				id := UUID()
+				mu.Lock()
+				ids[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(ids, 1000)
+	assert.Len(UUID(), 64)
+}
+
+func TestNewID(t *testing.T) {
+	assert := assert.New(t)
+	assert.Len(NewID(IDStrategyUUID), 36)
+	assert.Len(NewID(IDStrategyUUIDv7), 36)
+	assert.Len(NewID(IDStrategyULID), 26)
+	assert.Len(NewID(IDStrategyHash), 64)
+	assert.Equal("", NewID(IDStrategyAutoIncrement))
+	assert.Len(NewIDBytes(IDStrategyUUID), 16)
+	assert.Len(NewIDBytes(IDStrategyULID), 16)
+	assert.Len(NewIDBytes(IDStrategyHash), 16)
+	assert.Nil(NewIDBytes(IDStrategyNone))
+}
+
+func TestAutoIncrement(t *testing.T) {
+	assert := assert.New(t)
+	assert.False(ToSQLAutoIncrement(int32(0)).Valid)
+	v := ToSQLAutoIncrement(int32(10))
+	assert.True(v.Valid)
+	assert.Equal(int64(10), v.Int64)
+}
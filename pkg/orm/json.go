@@ -0,0 +1,226 @@
+package orm
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	structpb "github.com/golang/protobuf/ptypes/struct"
+)
+
+// JSONError is returned when the value of a JSON column cannot be encoded or decoded
+type JSONError struct {
+	Column string
+	Op     string
+	Err    error
+}
+
+func (e *JSONError) Error() string {
+	return fmt.Sprintf("orm: error %s JSON column `%s`: %v", e.Op, e.Column, e.Err)
+}
+
+// Unwrap returns the underlying encoding error
+func (e *JSONError) Unwrap() error {
+	return e.Err
+}
+
+func isNilValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		{
+			return rv.IsNil()
+		}
+	}
+	return false
+}
+
+// ToSQLJSON returns a sql.NullString with the JSON encoding of the value for the column. proto messages are encoded with jsonpb
+func ToSQLJSON(column string, v interface{}) (sql.NullString, error) {
+	if isNilValue(v) {
+		return sql.NullString{}, nil
+	}
+	if m, ok := v.(proto.Message); ok {
+		marshaler := &jsonpb.Marshaler{OrigName: true}
+		s, err := marshaler.MarshalToString(m)
+		if err != nil {
+			return sql.NullString{}, &JSONError{column, "encoding", err}
+		}
+		return sql.NullString{String: s, Valid: true}, nil
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return sql.NullString{}, &JSONError{column, "encoding", err}
+	}
+	return sql.NullString{String: string(buf), Valid: true}, nil
+}
+
+// FromSQLJSON decodes the JSON value of the column into v which must be a pointer to the field. if the field is a nil pointer a new value will be allocated. proto messages are decoded with jsonpb
+func FromSQLJSON(column string, value sql.NullString, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return &JSONError{column, "decoding", fmt.Errorf("expected a pointer but was %T", v)}
+	}
+	field := rv.Elem()
+	if value.Valid == false || value.String == "" || value.String == "null" {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	target := rv
+	if field.Kind() == reflect.Ptr {
+		target = reflect.New(field.Type().Elem())
+	}
+	if isStructMessage(target.Interface()) {
+		if err := unmarshalStruct(value.String, target.Interface()); err != nil {
+			return &JSONError{column, "decoding", err}
+		}
+	} else if m, ok := target.Interface().(proto.Message); ok {
+		if err := jsonpb.UnmarshalString(value.String, m); err != nil {
+			return &JSONError{column, "decoding", err}
+		}
+	} else {
+		if err := json.Unmarshal([]byte(value.String), target.Interface()); err != nil {
+			return &JSONError{column, "decoding", err}
+		}
+	}
+	if target != rv {
+		field.Set(target)
+	}
+	return nil
+}
+
+// MarshalJSONString returns the encoding/json encoding of the value for a JSON column mapped to a plain Go type. a nil value is encoded as an empty string which is written as NULL
+func MarshalJSONString(column string, v interface{}) (string, error) {
+	if isNilValue(v) {
+		return "", nil
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return "", &JSONError{column, "encoding", err}
+	}
+	return string(buf), nil
+}
+
+// UnmarshalJSONString decodes the JSON value of a column mapped to a plain Go type into v with encoding/json. an empty or null value leaves v unchanged
+func UnmarshalJSONString(column string, value string, v interface{}) error {
+	if value == "" || value == "null" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return &JSONError{column, "decoding", err}
+	}
+	return nil
+}
+
+func isStructMessage(v interface{}) bool {
+	switch v.(type) {
+	case *structpb.Struct, *structpb.Value, *structpb.ListValue:
+		{
+			return true
+		}
+	}
+	return false
+}
+
+// jsonpb is unable to unmarshal the struct well known types so we decode them ourselves
+func unmarshalStruct(s string, v interface{}) error {
+	var i interface{}
+	if err := json.Unmarshal([]byte(s), &i); err != nil {
+		return err
+	}
+	value := toStructValue(i)
+	switch t := v.(type) {
+	case *structpb.Struct:
+		{
+			st := value.GetStructValue()
+			if st == nil {
+				return fmt.Errorf("expected a JSON object but was %s", s)
+			}
+			*t = *st
+		}
+	case *structpb.ListValue:
+		{
+			lv := value.GetListValue()
+			if lv == nil {
+				return fmt.Errorf("expected a JSON array but was %s", s)
+			}
+			*t = *lv
+		}
+	case *structpb.Value:
+		{
+			*t = *value
+		}
+	}
+	return nil
+}
+
+func toStructValue(i interface{}) *structpb.Value {
+	switch v := i.(type) {
+	case nil:
+		return &structpb.Value{Kind: &structpb.Value_NullValue{}}
+	case bool:
+		return &structpb.Value{Kind: &structpb.Value_BoolValue{BoolValue: v}}
+	case float64:
+		return &structpb.Value{Kind: &structpb.Value_NumberValue{NumberValue: v}}
+	case string:
+		return &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: v}}
+	case []interface{}:
+		lv := &structpb.ListValue{Values: make([]*structpb.Value, 0, len(v))}
+		for _, e := range v {
+			lv.Values = append(lv.Values, toStructValue(e))
+		}
+		return &structpb.Value{Kind: &structpb.Value_ListValue{ListValue: lv}}
+	case map[string]interface{}:
+		st := &structpb.Struct{Fields: make(map[string]*structpb.Value)}
+		for k, e := range v {
+			st.Fields[k] = toStructValue(e)
+		}
+		return &structpb.Value{Kind: &structpb.Value_StructValue{StructValue: st}}
+	}
+	return &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: fmt.Sprintf("%v", i)}}
+}
+
+// jsonPath returns the path relative to the document root when it doesn't start with $
+func jsonPath(path string) string {
+	if strings.HasPrefix(path, "$") == false {
+		return "$." + path
+	}
+	return path
+}
+
+// JSONExtract returns a JSON_EXTRACT expression for the path in the JSON column. a path without a leading $ is relative to the document root. the path is bound as a parameter
+func JSONExtract(column string, path string) ExprDef {
+	return Expr("JSON_EXTRACT("+QuoteIdentifier(column)+", ?)", jsonPath(path))
+}
+
+// JSONUnquote returns an unquoted extraction (the same as the ->> operator) of the path in the JSON column. the path is bound as a parameter
+func JSONUnquote(column string, path string) ExprDef {
+	return Expr("JSON_UNQUOTE(JSON_EXTRACT("+QuoteIdentifier(column)+", ?))", jsonPath(path))
+}
+
+// IsJSONEqual returns a condition comparing the unquoted value at path in the JSON column
+func IsJSONEqual(column string, path string, value interface{}) ConditionDef {
+	return IsEqualExprDef(JSONUnquote(column, path), value)
+}
+
+// IsJSONNotEqual returns a condition which is true when the unquoted value at path in the JSON column is not equal
+func IsJSONNotEqual(column string, path string, value interface{}) ConditionDef {
+	return IsNotEqualExprDef(JSONUnquote(column, path), value)
+}
+
+// IsJSONNull returns a condition which is true when the path in the JSON column is missing
+func IsJSONNull(column string, path string) ConditionDef {
+	return IsNullExprDef(JSONExtract(column, path))
+}
+
+// IsJSONIn returns a condition which is true when the unquoted value at path in the JSON column is one of the values
+func IsJSONIn(column string, path string, value []interface{}) ConditionDef {
+	return IsInExprDef(JSONUnquote(column, path), value)
+}
@@ -0,0 +1,114 @@
+package orm
+
+import (
+	"database/sql"
+	"testing"
+
+	structpb "github.com/golang/protobuf/ptypes/struct"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONProto(t *testing.T) {
+	assert := assert.New(t)
+	s := &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"a": &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: "b"}},
+		},
+	}
+	v, err := ToSQLJSON("data", s)
+	assert.Nil(err)
+	assert.True(v.Valid)
+	assert.Equal(`{"a":"b"}`, v.String)
+
+	var d *structpb.Struct
+	err = FromSQLJSON("data", v, &d)
+	assert.Nil(err)
+	assert.NotNil(d)
+	assert.Equal("b", d.Fields["a"].GetStringValue())
+
+	err = FromSQLJSON("data", sql.NullString{}, &d)
+	assert.Nil(err)
+	assert.Nil(d)
+
+	v, err = ToSQLJSON("data", d)
+	assert.Nil(err)
+	assert.False(v.Valid)
+}
+
+func TestJSONValue(t *testing.T) {
+	assert := assert.New(t)
+	v, err := ToSQLJSON("data", map[string]int{"a": 1})
+	assert.Nil(err)
+	assert.Equal(`{"a":1}`, v.String)
+
+	var m map[string]int
+	err = FromSQLJSON("data", v, &m)
+	assert.Nil(err)
+	assert.Equal(1, m["a"])
+}
+
+func TestJSONString(t *testing.T) {
+	assert := assert.New(t)
+	type options struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+	s, err := MarshalJSONString("options", options{"a", 1})
+	assert.Nil(err)
+	assert.Equal(`{"name":"a","count":1}`, s)
+
+	var o options
+	assert.Nil(UnmarshalJSONString("options", s, &o))
+	assert.Equal(options{"a", 1}, o)
+
+	s, err = MarshalJSONString("options", (*options)(nil))
+	assert.Nil(err)
+	assert.Equal("", s)
+
+	var p *options
+	assert.Nil(UnmarshalJSONString("options", "", &p))
+	assert.Nil(p)
+
+	err = UnmarshalJSONString("options", "{", &o)
+	jerr, ok := err.(*JSONError)
+	assert.True(ok)
+	assert.Equal("decoding", jerr.Op)
+
+	_, err = MarshalJSONString("options", func() {})
+	jerr, ok = err.(*JSONError)
+	assert.True(ok)
+	assert.Equal("encoding", jerr.Op)
+}
+
+func TestJSONDecodeError(t *testing.T) {
+	assert := assert.New(t)
+	var d *structpb.Struct
+	err := FromSQLJSON("data", sql.NullString{String: "{", Valid: true}, &d)
+	assert.NotNil(err)
+	jerr, ok := err.(*JSONError)
+	assert.True(ok)
+	assert.Equal("data", jerr.Column)
+	assert.Equal("decoding", jerr.Op)
+
+	err = FromSQLJSON("data", sql.NullString{String: "{}", Valid: true}, d)
+	assert.NotNil(err)
+}
+
+func TestJSONQuery(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(Expr("JSON_EXTRACT(`data`, ?)", "$.a.b"), JSONExtract("data", "$.a.b"))
+	assert.Equal(Expr("JSON_EXTRACT(`data`, ?)", "$.a"), JSONExtract("data", "a"))
+	assert.Equal(Expr("JSON_UNQUOTE(JSON_EXTRACT(`data`, ?))", "$.it's"), JSONUnquote("data", "it's"))
+
+	q, p := BuildQuery(IsJSONEqual("data", "$.name", "foo"))
+	assert.Equal("WHERE JSON_UNQUOTE(JSON_EXTRACT(`data`, ?)) = ?", q)
+	assert.Equal([]interface{}{"$.name", "foo"}, p)
+
+	q, p = BuildQuery(IsJSONIn("data", "$.name", []interface{}{"a", "b"}))
+	assert.Equal("WHERE JSON_UNQUOTE(JSON_EXTRACT(`data`, ?)) IN (?,?)", q)
+	assert.Equal([]interface{}{"$.name", "a", "b"}, p)
+
+	q, p = BuildQuery(IsJSONNull("data", "$.name"))
+	assert.Equal("WHERE JSON_EXTRACT(`data`, ?) IS NULL", q)
+	assert.Equal([]interface{}{"$.name"}, p)
+}
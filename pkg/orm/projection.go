@@ -0,0 +1,58 @@
+package orm
+
+// NamedColumn is a column which can be passed to Only such as the typed columns and the field names of a generated table
+type NamedColumn interface {
+	ColumnName() string
+}
+
+// ColumnName returns the name of the column
+func (c Col) ColumnName() string {
+	return c.Name
+}
+
+// ProjectionDef limits the columns which are read by the generated FindPartial functions
+type ProjectionDef struct {
+	Names []string
+}
+
+// Only returns a projection which can be passed to a generated FindPartial function to read only the columns. the primary key is always read
+func Only(columns ...NamedColumn) ProjectionDef {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.ColumnName()
+	}
+	return ProjectionDef{names}
+}
+
+// OnlyNames returns a projection of the column names such as those from the fields parameter of an API request
+func OnlyNames(names ...string) ProjectionDef {
+	return ProjectionDef{names}
+}
+
+// With returns the names in the projection with the name added first if it isn't already in it. duplicate names are removed
+func (p ProjectionDef) With(name string) []string {
+	names := []string{name}
+	seen := NewAllowlist(name)
+	for _, n := range p.Names {
+		if seen.Allowed(n) == false {
+			seen[n] = true
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+// RejectProjection returns a QueryError if there's a projection in the params of a function which reads whole records since they could be written back over the columns which weren't read. partial is the function which reads the projection instead
+func RejectProjection(params []interface{}, partial string) error {
+	for _, param := range params {
+		if _, ok := param.(ProjectionDef); ok {
+			return &QueryError{"a projection can only be read with " + partial + " so that the columns which weren't read can't be overwritten"}
+		}
+	}
+	return nil
+}
+
+// RowScanner is a *sql.Row or *sql.Rows which can be scanned into the destinations of a projection
+type RowScanner interface {
+	Scan(dest ...interface{}) error
+}
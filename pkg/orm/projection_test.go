@@ -0,0 +1,25 @@
+package orm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjection(t *testing.T) {
+	assert := assert.New(t)
+	p := Only(StringColumn{Col{"name"}}, Col{"data"}, Int64Column{Col{"id"}})
+	assert.Equal([]string{"name", "data", "id"}, p.Names)
+	assert.Equal([]string{"id", "name", "data"}, p.With("id"))
+	assert.Equal([]string{"id", "a"}, OnlyNames("a", "a").With("id"))
+	assert.Equal([]string{"id"}, OnlyNames().With("id"))
+}
+
+func TestRejectProjection(t *testing.T) {
+	assert := assert.New(t)
+	assert.Nil(RejectProjection([]interface{}{IsEqual("a", 1), Limit(1)}, "FindUsersPartial"))
+	err := RejectProjection([]interface{}{IsEqual("a", 1), OnlyNames("a")}, "FindUsersPartial")
+	assert.True(errors.Is(err, ErrInvalidQuery))
+	assert.Contains(err.Error(), "FindUsersPartial")
+}
@@ -12,9 +12,9 @@ type TableDef struct {
 }
 
 func (t TableDef) String() string {
-	var n = "`" + t.Name + "`"
+	var n = QuoteIdentifier(t.Name)
 	if t.Alias != "" {
-		return n + " `" + t.Alias + "`"
+		return n + " " + QuoteIdentifier(t.Alias)
 	}
 	return n
 }
@@ -36,28 +36,64 @@ type ColumnDef struct {
 	Name  string
 	Alias string
 	Table string
-	Expr  string
+	// Func is a function applied to the column such as SUM. only the functions in columnFuncs can be used
+	Func string
+	// Expression is selected instead of the column
+	Expression ExprDef
+	// Expr is a function applied to the column when there's a name and is otherwise selected as is instead of the column
+	//
+	// Deprecated: use Func or Expression which binds the values of an Expr
+	Expr string
+}
+
+// the functions which can be applied to a column
+var columnFuncs = map[string]bool{
+	"SUM":       true,
+	"COUNT":     true,
+	"MIN":       true,
+	"MAX":       true,
+	"AVG":       true,
+	"ASTEXT":    true,
+	"ST_ASTEXT": true,
+}
+
+// function returns the function applied to the column which is set by the deprecated Expr when there's a name and no Func
+func (f ColumnDef) function() string {
+	if f.Func == "" && f.Name != "" && f.Expression.SQL == "" {
+		return f.Expr
+	}
+	return f.Func
+}
+
+// validFunc returns true if the function is one which can be applied to a column
+func (f ColumnDef) validFunc() bool {
+	fn := f.function()
+	return fn == "" || columnFuncs[strings.ToUpper(fn)]
 }
 
+// String returns the column as it's selected. nothing is written for a function which can't be applied so that the query isn't valid SQL and BuildQueryE returns the error
 func (f ColumnDef) String() string {
+	if f.validFunc() == false {
+		return ""
+	}
 	var n string
 	if f.Name != "*" {
-		n = "`" + f.Name + "`"
+		n = QuoteIdentifier(f.Name)
 	} else {
 		n = "*"
 	}
 	if f.Table != "" {
-		n = "`" + f.Table + "`." + n
+		n = QuoteIdentifier(f.Table) + "." + n
 	}
-	if f.Expr != "" {
-		if f.Name != "" {
-			n = f.Expr + "(" + n + ")"
-		} else {
-			n = f.Expr
-		}
+	if f.Expression.SQL != "" {
+		n = f.Expression.SQL
+	} else if fn := f.function(); fn != "" {
+		n = strings.ToUpper(fn) + "(" + n + ")"
+	} else if f.Expr != "" && f.Name == "" {
+		n = f.Expr
 	}
 	if f.Alias != "" {
-		return n + " AS `" + f.Alias + "`"
+		return n + " AS " + QuoteIdentifier(f.Alias)
 	}
 	return n
 }
@@ -75,19 +111,35 @@ func ColumnAlias(name, alias string) ColumnDef {
 	}
 }
 
-func ColumnExpr(expr string) ColumnDef {
+// ColumnExprDef returns a column for the expression such as Expr("DATE_FORMAT(`day`, ?)", "%Y-%m")
+func ColumnExprDef(expr ExprDef) ColumnDef {
 	return ColumnDef{
-		Expr: expr,
+		Expression: expr,
 	}
 }
 
-func ColumnExprAlias(expr, alias string) ColumnDef {
+// ColumnExpr returns a column for the SQL written as is
+//
+// Deprecated: use ColumnExprDef which binds the values of an Expr
+func ColumnExpr(expr string) ColumnDef {
+	return ColumnExprDef(Expr(expr))
+}
+
+// ColumnExprDefAlias returns an aliased column for the expression such as Expr("ROW_NUMBER() OVER (ORDER BY `id`)")
+func ColumnExprDefAlias(expr ExprDef, alias string) ColumnDef {
 	return ColumnDef{
-		Expr:  expr,
-		Alias: alias,
+		Expression: expr,
+		Alias:      alias,
 	}
 }
 
+// ColumnExprAlias returns an aliased column for the SQL written as is
+//
+// Deprecated: use ColumnExprDefAlias which binds the values of an Expr
+func ColumnExprAlias(expr string, alias string) ColumnDef {
+	return ColumnExprDefAlias(Expr(expr), alias)
+}
+
 func TableColumn(table, name string) ColumnDef {
 	return ColumnDef{
 		Table: table,
@@ -105,14 +157,14 @@ func TableColumnAlias(table, name, alias string) ColumnDef {
 
 func Sum(column string) ColumnDef {
 	return ColumnDef{
-		Expr: "SUM",
+		Func: "SUM",
 		Name: column,
 	}
 }
 
 func SumAlias(column, alias string) ColumnDef {
 	return ColumnDef{
-		Expr:  "SUM",
+		Func:  "SUM",
 		Name:  column,
 		Alias: alias,
 	}
@@ -120,14 +172,14 @@ func SumAlias(column, alias string) ColumnDef {
 
 func Count(column string) ColumnDef {
 	return ColumnDef{
-		Expr: "COUNT",
+		Func: "COUNT",
 		Name: column,
 	}
 }
 
 func CountAlias(column, alias string) ColumnDef {
 	return ColumnDef{
-		Expr:  "COUNT",
+		Func:  "COUNT",
 		Name:  column,
 		Alias: alias,
 	}
@@ -135,14 +187,14 @@ func CountAlias(column, alias string) ColumnDef {
 
 func Min(column string) ColumnDef {
 	return ColumnDef{
-		Expr: "MIN",
+		Func: "MIN",
 		Name: column,
 	}
 }
 
 func MinAlias(column, alias string) ColumnDef {
 	return ColumnDef{
-		Expr:  "MIN",
+		Func:  "MIN",
 		Name:  column,
 		Alias: alias,
 	}
@@ -150,14 +202,14 @@ func MinAlias(column, alias string) ColumnDef {
 
 func Max(column string) ColumnDef {
 	return ColumnDef{
-		Expr: "MAX",
+		Func: "MAX",
 		Name: column,
 	}
 }
 
 func MaxAlias(column, alias string) ColumnDef {
 	return ColumnDef{
-		Expr:  "MAX",
+		Func:  "MAX",
 		Name:  column,
 		Alias: alias,
 	}
@@ -175,10 +227,67 @@ const (
 	OperatorNull             Operator = "IS NULL"
 	OperatorNotNull          Operator = "IS NOT NULL"
 	OperatorIn               Operator = "IN"
+	OperatorNotIn            Operator = "NOT IN"
+	OperatorLike             Operator = "LIKE"
+	OperatorNotLike          Operator = "NOT LIKE"
+	OperatorBetween          Operator = "BETWEEN"
+	OperatorRegexp           Operator = "REGEXP"
+	OperatorNullSafeEqual    Operator = "<=>"
+	OperatorExists           Operator = "EXISTS"
+	OperatorNotExists        Operator = "NOT EXISTS"
 )
 
+// SubqueryDef is a query built with BuildQuery which can be used with IN or EXISTS
+type SubqueryDef struct {
+	Query  string
+	Params []interface{}
+	// err is the misuse of the components which is returned by the query the subquery is used in
+	err error
+}
+
+func (s SubqueryDef) String() string {
+	return "(" + s.Query + ")"
+}
+
+// Subquery builds a query from the components which can be used as the value of an IN or EXISTS condition. a misuse of the components is returned by BuildQueryE for the query it's used in
+func Subquery(components ...interface{}) SubqueryDef {
+	b := Query(components...)
+	err := b.validate()
+	q, p := b.build()
+	return SubqueryDef{Query: q, Params: p, err: err}
+}
+
+// conditionError returns the misuse in a condition such as a subquery which failed to build or a join on a column with a function which can't be applied
+func conditionError(condition Condition) error {
+	switch c := condition.(type) {
+	case ConditionDef:
+		if s, ok := c.Value.(SubqueryDef); ok && s.err != nil {
+			return &QueryError{"subquery " + s.err.Error()}
+		}
+	case ConditionGroupDef:
+		for _, g := range c.Conditions {
+			if err := conditionError(g); err != nil {
+				return err
+			}
+		}
+	case NotDef:
+		return conditionError(c.Condition)
+	case JoinDef:
+		for _, column := range []ColumnDef{c.A, c.B} {
+			if column.validFunc() == false {
+				return &QueryError{column.function() + " can't be applied to a column"}
+			}
+		}
+	}
+	return nil
+}
+
 type ConditionDef struct {
-	Name         string
+	Name string
+	Expr ExprDef
+	// Func is written as is instead of the column when there's no name or Expr
+	//
+	// Deprecated: use Expr which binds the values of an Expr
 	Func         string
 	Operator     Operator
 	OperatorExpr string
@@ -186,12 +295,16 @@ type ConditionDef struct {
 }
 
 func (f ConditionDef) AddValue(array []interface{}) []interface{} {
+	array = append(array, f.Expr.Params...)
+	if s, ok := f.Value.(SubqueryDef); ok {
+		return append(array, s.Params...)
+	}
 	switch f.Operator {
 	case OperatorNotNull, OperatorNull:
 		{
 			return array
 		}
-	case OperatorIn:
+	case OperatorIn, OperatorNotIn, OperatorBetween:
 		{
 			if a, ok := f.Value.([]interface{}); ok {
 				for _, i := range a {
@@ -207,7 +320,9 @@ func (f ConditionDef) AddValue(array []interface{}) []interface{} {
 func (f ConditionDef) String() string {
 	var lhs string
 	if f.Name != "" {
-		lhs = "`" + f.Name + "`"
+		lhs = QuoteIdentifier(f.Name)
+	} else if f.Expr.SQL != "" {
+		lhs = f.Expr.SQL
 	} else {
 		lhs = f.Func
 	}
@@ -216,8 +331,23 @@ func (f ConditionDef) String() string {
 		{
 			return lhs + " " + string(f.Operator)
 		}
-	case OperatorIn:
+	case OperatorExists, OperatorNotExists:
+		{
+			return string(f.Operator) + " (" + f.OperatorExpr + ")"
+		}
+	case OperatorBetween:
+		{
+			return lhs + " " + string(f.Operator) + " ? AND ?"
+		}
+	case OperatorIn, OperatorNotIn:
 		{
+			// nothing is in an empty list so IN never matches and NOT IN always matches
+			if a, ok := f.Value.([]interface{}); ok && len(a) == 0 {
+				if f.Operator == OperatorIn {
+					return "(1=0)"
+				}
+				return "(1=1)"
+			}
 			if f.OperatorExpr == "" {
 				return lhs + " " + string(f.Operator) + " (?)"
 			}
@@ -234,12 +364,26 @@ const (
 	Or  AndOr = "OR"
 )
 
+// Condition is a part of a WHERE clause such as a ConditionDef or a ConditionGroupDef which can be nested in a group
+type Condition interface {
+	String() string
+	AddValue(params []interface{}) []interface{}
+}
+
+// ConditionGroupDef joins its conditions, which can be other groups, with AND or OR inside parentheses
 type ConditionGroupDef struct {
-	Conditions []ConditionDef
+	Conditions []Condition
 	AndOr      AndOr
 }
 
 func (g ConditionGroupDef) String() string {
+	if len(g.Conditions) == 0 {
+		// an empty AND matches everything and an empty OR matches nothing
+		if g.AndOr == Or {
+			return "(1=0)"
+		}
+		return "(1=1)"
+	}
 	var buf bytes.Buffer
 	buf.WriteString("(")
 	l := len(g.Conditions)
@@ -253,11 +397,9 @@ func (g ConditionGroupDef) String() string {
 	return buf.String()
 }
 
-func (g *ConditionGroupDef) Add(conditions ...ConditionDef) {
-	g.Conditions = make([]ConditionDef, 0)
-	for _, condition := range conditions {
-		g.Conditions = append(g.Conditions, condition)
-	}
+// Add appends the conditions to the group
+func (g *ConditionGroupDef) Add(conditions ...Condition) {
+	g.Conditions = append(g.Conditions, conditions...)
 }
 
 func (g ConditionGroupDef) AddValue(params []interface{}) []interface{} {
@@ -267,7 +409,8 @@ func (g ConditionGroupDef) AddValue(params []interface{}) []interface{} {
 	return params
 }
 
-func OrGrouping(conditions ...ConditionDef) ConditionGroupDef {
+// OrGrouping returns a group which is true when any of the conditions are true
+func OrGrouping(conditions ...Condition) ConditionGroupDef {
 	g := &ConditionGroupDef{
 		AndOr: Or,
 	}
@@ -275,7 +418,8 @@ func OrGrouping(conditions ...ConditionDef) ConditionGroupDef {
 	return *g
 }
 
-func AndGrouping(conditions ...ConditionDef) ConditionGroupDef {
+// AndGrouping returns a group which is true when all of the conditions are true
+func AndGrouping(conditions ...Condition) ConditionGroupDef {
 	g := &ConditionGroupDef{
 		AndOr: And,
 	}
@@ -283,7 +427,30 @@ func AndGrouping(conditions ...ConditionDef) ConditionGroupDef {
 	return *g
 }
 
+// NotDef negates a condition
+type NotDef struct {
+	Condition Condition
+}
+
+func (n NotDef) String() string {
+	return "NOT (" + n.Condition.String() + ")"
+}
+
+// AddValue appends the values of the negated condition to the parameters
+func (n NotDef) AddValue(params []interface{}) []interface{} {
+	return n.Condition.AddValue(params)
+}
+
+// Not returns a condition which is true when the condition is false
+func Not(condition Condition) NotDef {
+	return NotDef{condition}
+}
+
+// IsEqual returns a condition comparing the column to the value. a nil value is compared with IS NULL
 func IsEqual(name string, value interface{}) ConditionDef {
+	if isNilValue(value) {
+		return IsNull(name)
+	}
 	return ConditionDef{
 		Name:     name,
 		Operator: OperatorEqual,
@@ -291,15 +458,30 @@ func IsEqual(name string, value interface{}) ConditionDef {
 	}
 }
 
-func IsEqualExpr(expr string, value interface{}) ConditionDef {
+// IsEqualExprDef returns a condition comparing the expression to the value. a nil value is compared with IS NULL
+func IsEqualExprDef(expr ExprDef, value interface{}) ConditionDef {
+	if isNilValue(value) {
+		return IsNullExprDef(expr)
+	}
 	return ConditionDef{
-		Func:     expr,
+		Expr:     expr,
 		Operator: OperatorEqual,
 		Value:    value,
 	}
 }
 
+// IsEqualExpr returns the condition of IsEqualExprDef for the SQL written as is
+//
+// Deprecated: use IsEqualExprDef which binds the values of an Expr
+func IsEqualExpr(expr string, value interface{}) ConditionDef {
+	return IsEqualExprDef(Expr(expr), value)
+}
+
+// IsNotEqual returns a condition which is true when the column is not equal to the value. a nil value is compared with IS NOT NULL
 func IsNotEqual(name string, value interface{}) ConditionDef {
+	if isNilValue(value) {
+		return IsNotNull(name)
+	}
 	return ConditionDef{
 		Name:     name,
 		Operator: OperatorNotEqual,
@@ -307,14 +489,25 @@ func IsNotEqual(name string, value interface{}) ConditionDef {
 	}
 }
 
-func IsNotEqualExpr(expr string, value interface{}) ConditionDef {
+// IsNotEqualExprDef returns a condition which is true when the expression is not equal to the value. a nil value is compared with IS NOT NULL
+func IsNotEqualExprDef(expr ExprDef, value interface{}) ConditionDef {
+	if isNilValue(value) {
+		return IsNotExprDef(expr)
+	}
 	return ConditionDef{
-		Func:     expr,
+		Expr:     expr,
 		Operator: OperatorNotEqual,
 		Value:    value,
 	}
 }
 
+// IsNotEqualExpr returns the condition of IsNotEqualExprDef for the SQL written as is
+//
+// Deprecated: use IsNotEqualExprDef which binds the values of an Expr
+func IsNotEqualExpr(expr string, value interface{}) ConditionDef {
+	return IsNotEqualExprDef(Expr(expr), value)
+}
+
 func IsGreaterThan(name string, value interface{}) ConditionDef {
 	return ConditionDef{
 		Name:     name,
@@ -323,14 +516,21 @@ func IsGreaterThan(name string, value interface{}) ConditionDef {
 	}
 }
 
-func IsGreaterThanExpr(expr string, value interface{}) ConditionDef {
+func IsGreaterThanExprDef(expr ExprDef, value interface{}) ConditionDef {
 	return ConditionDef{
-		Func:     expr,
+		Expr:     expr,
 		Operator: OperatorGreaterThan,
 		Value:    value,
 	}
 }
 
+// IsGreaterThanExpr returns the condition of IsGreaterThanExprDef for the SQL written as is
+//
+// Deprecated: use IsGreaterThanExprDef which binds the values of an Expr
+func IsGreaterThanExpr(expr string, value interface{}) ConditionDef {
+	return IsGreaterThanExprDef(Expr(expr), value)
+}
+
 func IsGreaterThanEqual(name string, value interface{}) ConditionDef {
 	return ConditionDef{
 		Name:     name,
@@ -339,14 +539,21 @@ func IsGreaterThanEqual(name string, value interface{}) ConditionDef {
 	}
 }
 
-func IsGreaterThanEqualExpr(expr string, value interface{}) ConditionDef {
+func IsGreaterThanEqualExprDef(expr ExprDef, value interface{}) ConditionDef {
 	return ConditionDef{
-		Func:     expr,
+		Expr:     expr,
 		Operator: OperatorGreaterThanEqual,
 		Value:    value,
 	}
 }
 
+// IsGreaterThanEqualExpr returns the condition of IsGreaterThanEqualExprDef for the SQL written as is
+//
+// Deprecated: use IsGreaterThanEqualExprDef which binds the values of an Expr
+func IsGreaterThanEqualExpr(expr string, value interface{}) ConditionDef {
+	return IsGreaterThanEqualExprDef(Expr(expr), value)
+}
+
 func IsLessThan(name string, value interface{}) ConditionDef {
 	return ConditionDef{
 		Name:     name,
@@ -355,14 +562,21 @@ func IsLessThan(name string, value interface{}) ConditionDef {
 	}
 }
 
-func IsLessThanExpr(expr string, value interface{}) ConditionDef {
+func IsLessThanExprDef(expr ExprDef, value interface{}) ConditionDef {
 	return ConditionDef{
-		Func:     expr,
+		Expr:     expr,
 		Operator: OperatorLessThan,
 		Value:    value,
 	}
 }
 
+// IsLessThanExpr returns the condition of IsLessThanExprDef for the SQL written as is
+//
+// Deprecated: use IsLessThanExprDef which binds the values of an Expr
+func IsLessThanExpr(expr string, value interface{}) ConditionDef {
+	return IsLessThanExprDef(Expr(expr), value)
+}
+
 func IsLessThanEqual(name string, value interface{}) ConditionDef {
 	return ConditionDef{
 		Name:     name,
@@ -371,14 +585,21 @@ func IsLessThanEqual(name string, value interface{}) ConditionDef {
 	}
 }
 
-func IsLessThanEqualExpr(expr string, value interface{}) ConditionDef {
+func IsLessThanEqualExprDef(expr ExprDef, value interface{}) ConditionDef {
 	return ConditionDef{
-		Func:     expr,
+		Expr:     expr,
 		Operator: OperatorLessThanEqual,
 		Value:    value,
 	}
 }
 
+// IsLessThanEqualExpr returns the condition of IsLessThanEqualExprDef for the SQL written as is
+//
+// Deprecated: use IsLessThanEqualExprDef which binds the values of an Expr
+func IsLessThanEqualExpr(expr string, value interface{}) ConditionDef {
+	return IsLessThanEqualExprDef(Expr(expr), value)
+}
+
 func IsNull(name string) ConditionDef {
 	return ConditionDef{
 		Name:     name,
@@ -386,13 +607,20 @@ func IsNull(name string) ConditionDef {
 	}
 }
 
-func IsNullExpr(expr string) ConditionDef {
+func IsNullExprDef(expr ExprDef) ConditionDef {
 	return ConditionDef{
-		Func:     expr,
+		Expr:     expr,
 		Operator: OperatorNull,
 	}
 }
 
+// IsNullExpr returns the condition of IsNullExprDef for the SQL written as is
+//
+// Deprecated: use IsNullExprDef which binds the values of an Expr
+func IsNullExpr(expr string) ConditionDef {
+	return IsNullExprDef(Expr(expr))
+}
+
 func IsNotNull(name string) ConditionDef {
 	return ConditionDef{
 		Name:     name,
@@ -400,15 +628,25 @@ func IsNotNull(name string) ConditionDef {
 	}
 }
 
-func IsNotExpr(expr string) ConditionDef {
+func IsNotExprDef(expr ExprDef) ConditionDef {
 	return ConditionDef{
-		Func:     expr,
+		Expr:     expr,
 		Operator: OperatorNotNull,
 	}
 }
 
+// IsNotExpr returns the condition of IsNotExprDef for the SQL written as is
+//
+// Deprecated: use IsNotExprDef which binds the values of an Expr
+func IsNotExpr(expr string) ConditionDef {
+	return IsNotExprDef(Expr(expr))
+}
+
 func makeInExpr(value []interface{}) string {
 	l := len(value)
+	if l == 0 {
+		return ""
+	}
 	return "?" + strings.Repeat(",?", l-1)
 }
 
@@ -421,15 +659,234 @@ func IsIn(name string, value []interface{}) ConditionDef {
 	}
 }
 
-func IsInExpr(expr string, value []interface{}) ConditionDef {
+func IsInExprDef(expr ExprDef, value []interface{}) ConditionDef {
 	return ConditionDef{
-		Func:         expr,
+		Expr:         expr,
 		Operator:     OperatorIn,
 		OperatorExpr: makeInExpr(value),
 		Value:        value,
 	}
 }
 
+// IsInExpr returns the condition of IsInExprDef for the SQL written as is
+//
+// Deprecated: use IsInExprDef which binds the values of an Expr
+func IsInExpr(expr string, value []interface{}) ConditionDef {
+	return IsInExprDef(Expr(expr), value)
+}
+
+// IsNotIn returns a condition which is true when the column is not one of the values
+func IsNotIn(name string, value []interface{}) ConditionDef {
+	return ConditionDef{
+		Name:         name,
+		Operator:     OperatorNotIn,
+		OperatorExpr: makeInExpr(value),
+		Value:        value,
+	}
+}
+
+// IsNotInExprDef returns a condition which is true when the expression is not one of the values
+func IsNotInExprDef(expr ExprDef, value []interface{}) ConditionDef {
+	return ConditionDef{
+		Expr:         expr,
+		Operator:     OperatorNotIn,
+		OperatorExpr: makeInExpr(value),
+		Value:        value,
+	}
+}
+
+// IsNotInExpr returns the condition of IsNotInExprDef for the SQL written as is
+//
+// Deprecated: use IsNotInExprDef which binds the values of an Expr
+func IsNotInExpr(expr string, value []interface{}) ConditionDef {
+	return IsNotInExprDef(Expr(expr), value)
+}
+
+// IsInSubquery returns a condition which is true when the column is one of the rows of the subquery
+func IsInSubquery(name string, subquery SubqueryDef) ConditionDef {
+	return ConditionDef{
+		Name:         name,
+		Operator:     OperatorIn,
+		OperatorExpr: subquery.Query,
+		Value:        subquery,
+	}
+}
+
+// IsNotInSubquery returns a condition which is true when the column is not one of the rows of the subquery
+func IsNotInSubquery(name string, subquery SubqueryDef) ConditionDef {
+	return ConditionDef{
+		Name:         name,
+		Operator:     OperatorNotIn,
+		OperatorExpr: subquery.Query,
+		Value:        subquery,
+	}
+}
+
+// Exists returns a condition which is true when the subquery returns a row
+func Exists(subquery SubqueryDef) ConditionDef {
+	return ConditionDef{
+		Operator:     OperatorExists,
+		OperatorExpr: subquery.Query,
+		Value:        subquery,
+	}
+}
+
+// NotExists returns a condition which is true when the subquery doesn't return a row
+func NotExists(subquery SubqueryDef) ConditionDef {
+	return ConditionDef{
+		Operator:     OperatorNotExists,
+		OperatorExpr: subquery.Query,
+		Value:        subquery,
+	}
+}
+
+// IsLike returns a condition matching the column to a LIKE pattern. use EscapeLike for user input in the pattern
+func IsLike(name string, pattern string) ConditionDef {
+	return ConditionDef{
+		Name:     name,
+		Operator: OperatorLike,
+		Value:    pattern,
+	}
+}
+
+// IsLikeExprDef returns a condition matching the expression to a LIKE pattern
+func IsLikeExprDef(expr ExprDef, pattern string) ConditionDef {
+	return ConditionDef{
+		Expr:     expr,
+		Operator: OperatorLike,
+		Value:    pattern,
+	}
+}
+
+// IsLikeExpr returns the condition of IsLikeExprDef for the SQL written as is
+//
+// Deprecated: use IsLikeExprDef which binds the values of an Expr
+func IsLikeExpr(expr string, pattern string) ConditionDef {
+	return IsLikeExprDef(Expr(expr), pattern)
+}
+
+// IsNotLike returns a condition which is true when the column doesn't match the LIKE pattern
+func IsNotLike(name string, pattern string) ConditionDef {
+	return ConditionDef{
+		Name:     name,
+		Operator: OperatorNotLike,
+		Value:    pattern,
+	}
+}
+
+// IsNotLikeExprDef returns a condition which is true when the expression doesn't match the LIKE pattern
+func IsNotLikeExprDef(expr ExprDef, pattern string) ConditionDef {
+	return ConditionDef{
+		Expr:     expr,
+		Operator: OperatorNotLike,
+		Value:    pattern,
+	}
+}
+
+// IsNotLikeExpr returns the condition of IsNotLikeExprDef for the SQL written as is
+//
+// Deprecated: use IsNotLikeExprDef which binds the values of an Expr
+func IsNotLikeExpr(expr string, pattern string) ConditionDef {
+	return IsNotLikeExprDef(Expr(expr), pattern)
+}
+
+// EscapeLike escapes the LIKE wildcards % and _ and the escape character so that s is matched literally
+func EscapeLike(s string) string {
+	s = strings.Replace(s, "\\", "\\\\", -1)
+	s = strings.Replace(s, "%", "\\%", -1)
+	return strings.Replace(s, "_", "\\_", -1)
+}
+
+// LikeContains returns a LIKE pattern matching values which contain s
+func LikeContains(s string) string {
+	return "%" + EscapeLike(s) + "%"
+}
+
+// LikePrefix returns a LIKE pattern matching values which start with s
+func LikePrefix(s string) string {
+	return EscapeLike(s) + "%"
+}
+
+// LikeSuffix returns a LIKE pattern matching values which end with s
+func LikeSuffix(s string) string {
+	return "%" + EscapeLike(s)
+}
+
+// IsBetween returns a condition which is true when the column is between the values inclusively
+func IsBetween(name string, from interface{}, to interface{}) ConditionDef {
+	return ConditionDef{
+		Name:     name,
+		Operator: OperatorBetween,
+		Value:    []interface{}{from, to},
+	}
+}
+
+// IsBetweenExprDef returns a condition which is true when the expression is between the values inclusively
+func IsBetweenExprDef(expr ExprDef, from interface{}, to interface{}) ConditionDef {
+	return ConditionDef{
+		Expr:     expr,
+		Operator: OperatorBetween,
+		Value:    []interface{}{from, to},
+	}
+}
+
+// IsBetweenExpr returns the condition of IsBetweenExprDef for the SQL written as is
+//
+// Deprecated: use IsBetweenExprDef which binds the values of an Expr
+func IsBetweenExpr(expr string, from interface{}, to interface{}) ConditionDef {
+	return IsBetweenExprDef(Expr(expr), from, to)
+}
+
+// IsRegexp returns a condition matching the column to a regular expression
+func IsRegexp(name string, pattern string) ConditionDef {
+	return ConditionDef{
+		Name:     name,
+		Operator: OperatorRegexp,
+		Value:    pattern,
+	}
+}
+
+// IsRegexpExprDef returns a condition matching the expression to a regular expression
+func IsRegexpExprDef(expr ExprDef, pattern string) ConditionDef {
+	return ConditionDef{
+		Expr:     expr,
+		Operator: OperatorRegexp,
+		Value:    pattern,
+	}
+}
+
+// IsRegexpExpr returns the condition of IsRegexpExprDef for the SQL written as is
+//
+// Deprecated: use IsRegexpExprDef which binds the values of an Expr
+func IsRegexpExpr(expr string, pattern string) ConditionDef {
+	return IsRegexpExprDef(Expr(expr), pattern)
+}
+
+// IsNullSafeEqual returns a condition comparing the column to the value with <=> which is true when both are NULL
+func IsNullSafeEqual(name string, value interface{}) ConditionDef {
+	return ConditionDef{
+		Name:     name,
+		Operator: OperatorNullSafeEqual,
+		Value:    value,
+	}
+}
+
+// IsNullSafeEqualExprDef returns a condition comparing the expression to the value with <=> which is true when both are NULL
+func IsNullSafeEqualExprDef(expr ExprDef, value interface{}) ConditionDef {
+	return ConditionDef{
+		Expr:     expr,
+		Operator: OperatorNullSafeEqual,
+		Value:    value,
+	}
+}
+
+// IsNullSafeEqualExpr returns the condition of IsNullSafeEqualExprDef for the SQL written as is
+//
+// Deprecated: use IsNullSafeEqualExprDef which binds the values of an Expr
+func IsNullSafeEqualExpr(expr string, value interface{}) ConditionDef {
+	return IsNullSafeEqualExprDef(Expr(expr), value)
+}
+
 type LimitDef struct {
 	Total int32
 }
@@ -467,8 +924,12 @@ type OrderDef struct {
 	Direction Direction
 }
 
+// String returns the order by the column. any direction other than descending is written as ascending and is an error from Builder.Build
 func (o OrderDef) String() string {
-	return "`" + o.Name + "` " + string(o.Direction)
+	if o.Direction == DirectionDescending {
+		return QuoteIdentifier(o.Name) + " " + string(DirectionDescending)
+	}
+	return QuoteIdentifier(o.Name) + " " + string(DirectionAscending)
 }
 
 func Ascending(name string) OrderDef {
@@ -481,127 +942,351 @@ func Descending(name string) OrderDef {
 
 type GroupDef struct {
 	Name string
+	Expr ExprDef
 }
 
 func (g GroupDef) String() string {
-	return "`" + g.Name + "`"
+	if g.Expr.SQL != "" {
+		return g.Expr.SQL
+	}
+	return QuoteIdentifier(g.Name)
+}
+
+// AddValue appends the values of the expression to the parameters
+func (g GroupDef) AddValue(params []interface{}) []interface{} {
+	return append(params, g.Expr.Params...)
 }
 
 func GroupBy(name string) GroupDef {
-	return GroupDef{name}
+	return GroupDef{Name: name}
+}
+
+// GroupByExprDef returns a grouping by the expression such as Expr("DATE_FORMAT(`day`, ?)", "%Y-%m")
+func GroupByExprDef(expr ExprDef) GroupDef {
+	return GroupDef{
+		Expr: expr,
+	}
+}
+
+// GroupByExpr returns a grouping by the SQL written as is with the values for its placeholders
+//
+// Deprecated: use GroupByExprDef which binds the values of an Expr
+func GroupByExpr(expr string, params ...interface{}) GroupDef {
+	return GroupByExprDef(Expr(expr, params...))
+}
+
+// HavingDef filters the groups of a GROUP BY with conditions which are joined with AND
+type HavingDef struct {
+	Conditions []Condition
+}
+
+func (h HavingDef) String() string {
+	return AndGrouping(h.Conditions...).String()
+}
+
+// AddValue appends the values of the conditions to the parameters
+func (h HavingDef) AddValue(params []interface{}) []interface{} {
+	return AndGrouping(h.Conditions...).AddValue(params)
+}
+
+// Having returns a HAVING clause for the conditions which can use aggregates such as IsGreaterThanExprDef(Expr("COUNT(*)"), 1)
+func Having(conditions ...Condition) HavingDef {
+	return HavingDef{conditions}
+}
+
+// DistinctDef makes the query a SELECT DISTINCT
+type DistinctDef struct{}
+
+// Distinct returns a component which removes the duplicate rows from the results of the query
+func Distinct() DistinctDef {
+	return DistinctDef{}
+}
+
+// UnionDef combines the results of a query with the results of another query
+type UnionDef struct {
+	All   bool
+	Query SubqueryDef
+}
+
+func (u UnionDef) String() string {
+	if u.All {
+		return "UNION ALL " + u.Query.String()
+	}
+	return "UNION " + u.Query.String()
+}
+
+// AddValue appends the values of the other query to the parameters
+func (u UnionDef) AddValue(params []interface{}) []interface{} {
+	return append(params, u.Query.Params...)
+}
+
+// Union returns a UNION with the query built from the components which removes the duplicate rows. an ORDER BY or LIMIT after it applies to the combined results
+func Union(components ...interface{}) UnionDef {
+	return UnionDef{Query: Subquery(components...)}
+}
+
+// UnionAll returns a UNION ALL with the query built from the components which keeps the duplicate rows
+func UnionAll(components ...interface{}) UnionDef {
+	return UnionDef{All: true, Query: Subquery(components...)}
 }
 
 type JoinDef struct {
-	A string
-	B string
+	A ColumnDef
+	B ColumnDef
 }
 
 func (j JoinDef) String() string {
-	return j.A + " = " + j.B
+	return j.A.String() + " = " + j.B.String()
 }
 
+// AddValue returns the parameters unchanged since a join condition has no values
+func (j JoinDef) AddValue(params []interface{}) []interface{} {
+	return params
+}
+
+// Join returns a condition comparing two columns which are either a name or a table and name separated by a dot such as "a.id"
 func Join(a, b string) JoinDef {
+	return JoinDef{joinColumn(a), joinColumn(b)}
+}
+
+func joinColumn(name string) ColumnDef {
+	if i := strings.Index(name, "."); i > 0 {
+		return TableColumn(name[0:i], name[i+1:])
+	}
+	return Column(name)
+}
+
+// JoinType is the kind of join to another table
+type JoinType string
+
+const (
+	JoinTypeInner JoinType = "INNER JOIN"
+	JoinTypeLeft  JoinType = "LEFT JOIN"
+	JoinTypeRight JoinType = "RIGHT JOIN"
+)
+
+// JoinTableDef joins a table to the tables before it with the ON conditions such as a JoinDef comparing two columns or a ConditionDef comparing a column to a value
+type JoinTableDef struct {
+	Type  JoinType
+	Table TableDef
+	On    []Condition
+}
+
+func (j JoinTableDef) String() string {
+	if len(j.On) == 0 {
+		return string(j.Type) + " " + j.Table.String()
+	}
+	conditions := make([]string, 0, len(j.On))
+	for _, on := range j.On {
+		conditions = append(conditions, on.String())
+	}
+	return string(j.Type) + " " + j.Table.String() + " ON " + strings.Join(conditions, " AND ")
+}
+
+// AddValue appends the values of the ON conditions to the parameters
+func (j JoinTableDef) AddValue(params []interface{}) []interface{} {
+	for _, on := range j.On {
+		params = on.AddValue(params)
+	}
+	return params
+}
+
+// InnerJoin returns an INNER JOIN of the table on the conditions
+func InnerJoin(table TableDef, on ...Condition) JoinTableDef {
+	return JoinTableDef{JoinTypeInner, table, on}
+}
+
+// LeftJoin returns a LEFT JOIN of the table on the conditions
+func LeftJoin(table TableDef, on ...Condition) JoinTableDef {
+	return JoinTableDef{JoinTypeLeft, table, on}
+}
+
+// RightJoin returns a RIGHT JOIN of the table on the conditions
+func RightJoin(table TableDef, on ...Condition) JoinTableDef {
+	return JoinTableDef{JoinTypeRight, table, on}
+}
+
+// On returns a join condition comparing two columns such as those returned by TableColumn
+func On(a, b ColumnDef) JoinDef {
 	return JoinDef{a, b}
 }
 
+// BuildQuery returns the query and parameters for the components written in SQL order. misuse such as an unsupported component or a second LIMIT is ignored so use BuildQueryE to have it returned as an error
 func BuildQuery(components ...interface{}) (string, []interface{}) {
-	var buf bytes.Buffer
-	var hasField, hasTable, hasWhere, hasGroup, hasOrder, hasLimit bool
-	params := make([]interface{}, 0)
-	for _, component := range components {
-		if f, ok := component.(ColumnDef); ok {
-			if hasField == false {
-				hasField = true
-				buf.WriteString("SELECT ")
-			} else {
-				buf.WriteString(", ")
-			}
-			buf.WriteString(f.String())
-			continue
-		}
-		if t, ok := component.(TableDef); ok {
-			if hasTable == false {
-				hasTable = true
-				buf.WriteString(" FROM ")
-			} else {
-				buf.WriteString(", ")
-			}
-			buf.WriteString(t.String())
-			continue
-		}
-		if g, ok := component.(ConditionGroupDef); ok {
-			if hasWhere == false {
-				hasWhere = true
-				buf.WriteString(" WHERE ")
-			} else {
-				buf.WriteString(" AND ")
-			}
-			buf.WriteString(g.String())
-			params = g.AddValue(params)
-			continue
-		}
-		if c, ok := component.(ConditionDef); ok {
-			if hasWhere == false {
-				hasWhere = true
-				buf.WriteString(" WHERE ")
-			} else {
-				buf.WriteString(" AND ")
-			}
-			buf.WriteString(c.String())
-			params = c.AddValue(params)
-			continue
+	return Query(components...).build()
+}
+
+// BuildQueryE returns the query and parameters for the components like BuildQuery or a QueryError for the first misuse
+func BuildQueryE(components ...interface{}) (string, []interface{}, error) {
+	return Query(components...).Build()
+}
+
+// AssignmentDef is a column assignment in the SET of an UPDATE
+type AssignmentDef struct {
+	Name string
+	Expr ExprDef
+}
+
+func (a AssignmentDef) String() string {
+	return QuoteIdentifier(a.Name) + " = " + a.Expr.SQL
+}
+
+// AddValue appends the values of the assignment to the parameters
+func (a AssignmentDef) AddValue(params []interface{}) []interface{} {
+	return append(params, a.Expr.Params...)
+}
+
+// Set returns an assignment of the value to the column
+func Set(name string, value interface{}) AssignmentDef {
+	return AssignmentDef{
+		Name: name,
+		Expr: Expr("?", value),
+	}
+}
+
+// SetExprDef returns an assignment of the expression to the column such as Expr("`count` + ?", 1)
+func SetExprDef(name string, expr ExprDef) AssignmentDef {
+	return AssignmentDef{
+		Name: name,
+		Expr: expr,
+	}
+}
+
+// SetExpr returns an assignment of the SQL written as is to the column with the values for its placeholders
+//
+// Deprecated: use SetExprDef which binds the values of an Expr
+func SetExpr(name string, expr string, values ...interface{}) AssignmentDef {
+	return SetExprDef(name, Expr(expr, values...))
+}
+
+// Increment returns an assignment which adds the value to the column
+func Increment(name string, value interface{}) AssignmentDef {
+	return SetExprDef(name, Expr(QuoteIdentifier(name)+" + ?", value))
+}
+
+// HasAssignment returns true if one of the assignments is to the column
+func HasAssignment(assignments []AssignmentDef, name string) bool {
+	for _, a := range assignments {
+		if a.Name == name {
+			return true
 		}
-		if j, ok := component.(JoinDef); ok {
-			if hasWhere == false {
-				hasWhere = true
-				buf.WriteString(" WHERE ")
-			} else {
-				buf.WriteString(" AND ")
-			}
-			buf.WriteString(j.String())
-			continue
+	}
+	return false
+}
+
+// splitJoins separates the joins from the conditions, orders and limit of an UPDATE or DELETE. columns and tables are dropped so BuildUpdateE and BuildDeleteE reject them
+func splitJoins(components []interface{}) ([]JoinTableDef, []interface{}) {
+	joins := make([]JoinTableDef, 0)
+	rest := make([]interface{}, 0, len(components))
+	for _, component := range components {
+		switch c := component.(type) {
+		case JoinTableDef:
+			joins = append(joins, c)
+		case ColumnDef, TableDef:
+		default:
+			rest = append(rest, c)
 		}
-		if g, ok := component.(GroupDef); ok {
-			if hasGroup == false {
-				hasGroup = true
-				buf.WriteString(" GROUP BY ")
-			} else {
-				buf.WriteString(", ")
-			}
-			buf.WriteString(g.String())
-			continue
+	}
+	return joins, rest
+}
+
+// BuildUpdate returns an UPDATE of the table with the assignments and the conditions, joins, orders and limit in the components. an empty query is returned if there are no assignments
+func BuildUpdate(table string, assignments []AssignmentDef, components ...interface{}) (string, []interface{}) {
+	if len(assignments) == 0 {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	params := make([]interface{}, 0)
+	joins, rest := splitJoins(components)
+	buf.WriteString("UPDATE " + QuoteIdentifier(table))
+	for _, j := range joins {
+		buf.WriteString(" " + j.String())
+		params = j.AddValue(params)
+	}
+	buf.WriteString(" SET ")
+	for i, a := range assignments {
+		if i > 0 {
+			buf.WriteString(", ")
 		}
-		if o, ok := component.(OrderDef); ok {
-			if hasOrder == false {
-				hasOrder = true
-				buf.WriteString(" ORDER BY ")
-			} else {
-				buf.WriteString(",")
-			}
-			buf.WriteString(o.String())
-			buf.WriteString(" ")
-			continue
+		buf.WriteString(a.String())
+		params = a.AddValue(params)
+	}
+	q, p := BuildQuery(rest...)
+	if q != "" {
+		buf.WriteString(" " + q)
+	}
+	return buf.String(), append(params, p...)
+}
+
+// validateModify returns a QueryError for the components of an UPDATE or DELETE which MySQL rejects or which would be dropped: a column or table, a LIMIT with an offset, an ORDER BY or LIMIT with joins and the clauses which only a SELECT has
+func validateModify(components []interface{}) error {
+	for _, component := range components {
+		switch component.(type) {
+		case ColumnDef, TableDef:
+			return &QueryError{fmt.Sprintf("%T can't be used in an UPDATE or DELETE since only the table passed is changed", component)}
 		}
-		if l, ok := component.(LimitDef); ok {
-			if hasLimit == false {
-				hasLimit = true
-				if strings.HasSuffix(buf.String(), " ") == false {
-					buf.WriteString(" ")
-				}
-				buf.WriteString(l.String())
-				continue
+	}
+	joins, rest := splitJoins(components)
+	for _, j := range joins {
+		for _, on := range j.On {
+			if err := conditionError(on); err != nil {
+				return err
 			}
 		}
-		if r, ok := component.(RangeDef); ok {
-			if hasLimit == false {
-				hasLimit = true
-				if strings.HasSuffix(buf.String(), " ") == false {
-					buf.WriteString(" ")
-				}
-				buf.WriteString(r.String())
-				continue
+	}
+	for _, component := range rest {
+		switch component.(type) {
+		case RangeDef:
+			return &QueryError{"an UPDATE or DELETE can't have a LIMIT with an offset"}
+		case OrderDef, LimitDef:
+			if len(joins) > 0 {
+				return &QueryError{fmt.Sprintf("%T can't be used in an UPDATE or DELETE with joins", component)}
 			}
+		case GroupDef, HavingDef, DistinctDef, UnionDef:
+			return &QueryError{fmt.Sprintf("%T can't be used in an UPDATE or DELETE", component)}
 		}
 	}
-	return strings.TrimSpace(buf.String()), params
+	return Query(rest...).validate()
+}
+
+// BuildUpdateE returns an UPDATE like BuildUpdate or a QueryError if there are no assignments or for the first misuse of the components
+func BuildUpdateE(table string, assignments []AssignmentDef, components ...interface{}) (string, []interface{}, error) {
+	if len(assignments) == 0 {
+		return "", nil, &QueryError{"an UPDATE needs assignments"}
+	}
+	if err := validateModify(components); err != nil {
+		return "", nil, err
+	}
+	q, p := BuildUpdate(table, assignments, components...)
+	return q, p, nil
+}
+
+// BuildDelete returns a DELETE from the table with the conditions, joins, orders and limit in the components. only the rows of the table are deleted when there are joins
+func BuildDelete(table string, components ...interface{}) (string, []interface{}) {
+	var buf bytes.Buffer
+	params := make([]interface{}, 0)
+	joins, rest := splitJoins(components)
+	if len(joins) > 0 {
+		buf.WriteString("DELETE " + QuoteIdentifier(table) + " FROM " + QuoteIdentifier(table))
+	} else {
+		buf.WriteString("DELETE FROM " + QuoteIdentifier(table))
+	}
+	for _, j := range joins {
+		buf.WriteString(" " + j.String())
+		params = j.AddValue(params)
+	}
+	q, p := BuildQuery(rest...)
+	if q != "" {
+		buf.WriteString(" " + q)
+	}
+	return buf.String(), append(params, p...)
+}
+
+// BuildDeleteE returns a DELETE like BuildDelete or a QueryError for the first misuse of the components
+func BuildDeleteE(table string, components ...interface{}) (string, []interface{}, error) {
+	if err := validateModify(components); err != nil {
+		return "", nil, err
+	}
+	q, p := BuildDelete(table, components...)
+	return q, p, nil
 }
@@ -1,6 +1,7 @@
 package orm
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -89,6 +90,17 @@ func TestQueryInArray(t *testing.T) {
 	assert.Equal("1, 2", JoinAsString(p))
 }
 
+func TestQueryInEmptyArray(t *testing.T) {
+	assert := assert.New(t)
+	q, p := BuildQuery(IsIn("a", []interface{}{}), IsEqual("b", 1))
+	assert.Equal("WHERE (1=0) AND `b` = ?", q)
+	assert.Equal([]interface{}{1}, p)
+
+	q, p = BuildQuery(IsNotIn("a", nil), IsNotInExpr("LOWER(b)", []interface{}{}), IsInExpr("c", []interface{}{}))
+	assert.Equal("WHERE (1=1) AND (1=1) AND (1=0)", q)
+	assert.Len(p, 0)
+}
+
 func TestQueryParams(t *testing.T) {
 	assert := assert.New(t)
 	q := IsEqual("foo", "bar")
@@ -112,6 +124,9 @@ func TestOrder(t *testing.T) {
 
 	desc := Descending("foo")
 	assert.Equal("`foo` DESC", desc.String())
+
+	bad := OrderDef{Name: "foo", Direction: "DESC; DROP TABLE foo"}
+	assert.Equal("`foo` ASC", bad.String())
 }
 
 func TestLimit(t *testing.T) {
@@ -247,13 +262,13 @@ func TestBuildQuery(t *testing.T) {
 	assert.Equal("GROUP BY `foo`, `bar` ORDER BY `bar` ASC", q)
 
 	q, p = BuildQuery(Join("a", "b"))
-	assert.Equal("WHERE a = b", q)
+	assert.Equal("WHERE `a` = `b`", q)
 
 	q, p = BuildQuery(Join("a.id", "b.some_id"))
-	assert.Equal("WHERE a.id = b.some_id", q)
+	assert.Equal("WHERE `a`.`id` = `b`.`some_id`", q)
 
 	q, p = BuildQuery(Join("a.id", "b.some_id"), Join("x", "y"))
-	assert.Equal("WHERE a.id = b.some_id AND x = y", q)
+	assert.Equal("WHERE `a`.`id` = `b`.`some_id` AND `x` = `y`", q)
 
 	q, p = BuildQuery(ColumnDef{Expr: "DATEDIFF(COALESCE(b.closed_at, NOW()), b.created_at)", Alias: "days_open"})
 	assert.Equal("SELECT DATEDIFF(COALESCE(b.closed_at, NOW()), b.created_at) AS `days_open`", q)
@@ -263,4 +278,271 @@ func TestBuildQuery(t *testing.T) {
 
 	q, p = BuildQuery(ColumnExpr("DATEDIFF(COALESCE(b.closed_at, NOW()), b.created_at)"))
 	assert.Equal("SELECT DATEDIFF(COALESCE(b.closed_at, NOW()), b.created_at)", q)
+
+	q, p = BuildQuery(ColumnDef{Name: "loc", Func: "astext"})
+	assert.Equal("SELECT ASTEXT(`loc`)", q)
+
+	q, p, err := BuildQueryE(ColumnDef{Name: "loc", Func: "astext"}, ColumnDef{Name: "id", Func: "SLEEP(1) OR "})
+	assert.True(errors.Is(err, ErrInvalidQuery), "%v", err)
+	assert.Equal("", q)
+	assert.Nil(p)
+	assert.Equal("", ColumnDef{Name: "id", Func: "SLEEP(1) OR "}.String())
+}
+
+func TestBuildQueryExprDef(t *testing.T) {
+	assert := assert.New(t)
+	q, p := BuildQuery(IsInExprDef(Expr("a"), []interface{}{"1", "2"}))
+	assert.Equal("WHERE a IN (?,?)", q)
+	assert.Equal([]interface{}{"1", "2"}, p)
+
+	q, p = BuildQuery(IsNotIn("a", nil), IsNotInExprDef(Expr("LOWER(b)"), []interface{}{}), IsInExprDef(Expr("c"), []interface{}{}))
+	assert.Equal("WHERE (1=1) AND (1=1) AND (1=0)", q)
+	assert.Len(p, 0)
+
+	q, p = BuildQuery(IsLessThanEqualExprDef(Expr("DATE(CONVERT_TZ(`date`, ?, ?))", "UTC", "-07:00"), "123"))
+	assert.Equal("WHERE DATE(CONVERT_TZ(`date`, ?, ?)) <= ?", q)
+	assert.Equal([]interface{}{"UTC", "-07:00", "123"}, p)
+
+	q, p = BuildQuery(ColumnDef{Expression: Expr("DATEDIFF(COALESCE(b.closed_at, NOW()), b.created_at)"), Alias: "days_open"})
+	assert.Equal("SELECT DATEDIFF(COALESCE(b.closed_at, NOW()), b.created_at) AS `days_open`", q)
+
+	q, p = BuildQuery(ColumnExprDefAlias(Expr("DATE_FORMAT(`day`, ?)", "%Y"), "year"), ColumnExprDef(Expr("1")), Table("x"), GroupByExprDef(Expr("DATE_FORMAT(`day`, ?)", "%Y")), Having(IsGreaterThanExprDef(Expr("COUNT(*)"), 10), IsLessThanExprDef(Expr("MAX(`size`)"), 100)))
+	assert.Equal("SELECT DATE_FORMAT(`day`, ?) AS `year`, 1 FROM `x` GROUP BY DATE_FORMAT(`day`, ?) HAVING (COUNT(*) > ? AND MAX(`size`) < ?)", q)
+	assert.Equal([]interface{}{"%Y", "%Y", 10, 100}, p)
+
+	q, p = BuildDelete("user", LeftJoin(Table("repo"), On(TableColumn("repo", "user_id"), TableColumn("user", "id")), IsEqualExprDef(Expr("`repo`.`active`"), true)), IsNullExprDef(Expr("`repo`.`id`")))
+	assert.Equal("DELETE `user` FROM `user` LEFT JOIN `repo` ON `repo`.`user_id` = `user`.`id` AND `repo`.`active` = ? WHERE `repo`.`id` IS NULL", q)
+	assert.Equal([]interface{}{true}, p)
+
+	q, p = BuildUpdate("user", []AssignmentDef{SetExprDef("score", Expr("GREATEST(`score`, ?)", 10))}, IsEqual("id", 5))
+	assert.Equal("UPDATE `user` SET `score` = GREATEST(`score`, ?) WHERE `id` = ?", q)
+	assert.Equal([]interface{}{10, 5}, p)
+}
+
+func TestBuildQueryDeprecated(t *testing.T) {
+	assert := assert.New(t)
+	q, p, err := BuildQueryE(ColumnDef{Table: "a", Name: "loc", Expr: "astext"}, ColumnExprAlias("COUNT(*)", "total"), Table("a"), IsGreaterThanExpr("LENGTH(`name`)", 1), ConditionDef{Func: "`a`.`id`", Operator: OperatorEqual, Value: 2}, GroupByExpr("DATE_FORMAT(`day`, ?)", "%Y"))
+	assert.Nil(err)
+	assert.Equal("SELECT ASTEXT(`a`.`loc`), COUNT(*) AS `total` FROM `a` WHERE LENGTH(`name`) > ? AND `a`.`id` = ? GROUP BY DATE_FORMAT(`day`, ?)", q)
+	assert.Equal([]interface{}{1, 2, "%Y"}, p)
+
+	q, p = BuildQuery(ColumnExpr("NOW()"), IsNullExpr("`a`"), IsInExpr("`b`", []interface{}{1}))
+	assert.Equal("SELECT NOW() WHERE `a` IS NULL AND `b` IN (?)", q)
+	assert.Equal([]interface{}{1}, p)
+
+	q, p = BuildUpdate("user", []AssignmentDef{SetExpr("score", "GREATEST(`score`, ?)", 10)})
+	assert.Equal("UPDATE `user` SET `score` = GREATEST(`score`, ?)", q)
+	assert.Equal([]interface{}{10}, p)
+
+	assert.Error(Allowlist{"a": true}.Check(ConditionDef{Func: "`a`", Operator: OperatorEqual, Value: 1}))
+	assert.Error(Allowlist{"a": true}.Check(ColumnDef{Expr: "`a`"}))
+}
+
+func TestBuildQueryJoin(t *testing.T) {
+	assert := assert.New(t)
+	q, p := BuildQuery(
+		TableColumn("a", "id"),
+		TableColumnAlias("u", "name", "user"),
+		TableAlias("activity_summary", "a"),
+		InnerJoin(TableAlias("user", "u"), On(TableColumn("u", "id"), TableColumn("a", "user_id"))),
+		LeftJoin(TableAlias("repo", "r"), On(TableColumn("r", "id"), TableColumn("a", "repo_id")), IsEqualExpr("`r`.`active`", true)),
+		IsGreaterThan("count", 1),
+	)
+	assert.Equal("SELECT `a`.`id`, `u`.`name` AS `user` FROM `activity_summary` `a` INNER JOIN `user` `u` ON `u`.`id` = `a`.`user_id` LEFT JOIN `repo` `r` ON `r`.`id` = `a`.`repo_id` AND `r`.`active` = ? WHERE `count` > ?", q)
+	assert.Equal([]interface{}{true, 1}, p)
+
+	q, _ = BuildQuery(Column("id"), Table("a"), RightJoin(Table("b")))
+	assert.Equal("SELECT `id` FROM `a` RIGHT JOIN `b`", q)
+
+	// the values of any condition are bound in the order they're written
+	q, p = BuildQuery(Column("id"), Table("a"), InnerJoin(Table("b"), OrGrouping(IsEqual("x", 1), Not(IsEqual("y", 2)))), IsEqual("z", 3))
+	assert.Equal("SELECT `id` FROM `a` INNER JOIN `b` ON (`x` = ? OR NOT (`y` = ?)) WHERE `z` = ?", q)
+	assert.Equal([]interface{}{1, 2, 3}, p)
+}
+
+func TestBuildQueryOperators(t *testing.T) {
+	assert := assert.New(t)
+	q, p := BuildQuery(IsEqual("a", nil), IsNotEqual("b", (*string)(nil)))
+	assert.Equal("WHERE `a` IS NULL AND `b` IS NOT NULL", q)
+	assert.Len(p, 0)
+
+	q, p = BuildQuery(IsLike("a", LikeContains("50%_off")), IsNotLike("b", LikePrefix("x")))
+	assert.Equal("WHERE `a` LIKE ? AND `b` NOT LIKE ?", q)
+	assert.Equal([]interface{}{"%50\\%\\_off%", "x%"}, p)
+	assert.Equal("%a\\\\b", LikeSuffix("a\\b"))
+
+	q, p = BuildQuery(IsBetween("a", 1, 2), IsNotIn("b", []interface{}{3, 4}), IsRegexp("c", "^x"), IsNullSafeEqual("d", nil))
+	assert.Equal("WHERE `a` BETWEEN ? AND ? AND `b` NOT IN (?,?) AND `c` REGEXP ? AND `d` <=> ?", q)
+	assert.Equal([]interface{}{1, 2, 3, 4, "^x", nil}, p)
+
+	sub := Subquery(Column("user_id"), Table("repo"), IsEqual("active", true))
+	q, p = BuildQuery(Column("id"), Table("user"), IsEqual("a", 1), IsInSubquery("id", sub), IsEqual("b", 2))
+	assert.Equal("SELECT `id` FROM `user` WHERE `a` = ? AND `id` IN (SELECT `user_id` FROM `repo` WHERE `active` = ?) AND `b` = ?", q)
+	assert.Equal([]interface{}{1, true, 2}, p)
+
+	q, p = BuildQuery(IsNotInSubquery("id", sub), NotExists(sub), Exists(Subquery(ColumnExpr("1"), Table("x"))))
+	assert.Equal("WHERE `id` NOT IN (SELECT `user_id` FROM `repo` WHERE `active` = ?) AND NOT EXISTS (SELECT `user_id` FROM `repo` WHERE `active` = ?) AND EXISTS (SELECT 1 FROM `x`)", q)
+	assert.Equal([]interface{}{true, true}, p)
+}
+
+func TestBuildQueryNestedGroups(t *testing.T) {
+	assert := assert.New(t)
+	q, p := BuildQuery(
+		IsEqual("z", 0),
+		OrGrouping(
+			AndGrouping(IsEqual("a", 1), IsEqual("b", 2)),
+			AndGrouping(IsEqual("c", 3), Not(OrGrouping(IsEqual("d", 4), IsIn("e", []interface{}{5, 6})))),
+		),
+	)
+	assert.Equal("WHERE `z` = ? AND ((`a` = ? AND `b` = ?) OR (`c` = ? AND NOT ((`d` = ? OR `e` IN (?,?)))))", q)
+	assert.Equal([]interface{}{0, 1, 2, 3, 4, 5, 6}, p)
+
+	g := AndGrouping(IsEqual("a", 1))
+	g.Add(IsEqual("b", 2))
+	g.Add(OrGrouping(IsNull("c"), IsEqual("c", 3)))
+	q, p = BuildQuery(g)
+	assert.Equal("WHERE (`a` = ? AND `b` = ? AND (`c` IS NULL OR `c` = ?))", q)
+	assert.Equal([]interface{}{1, 2, 3}, p)
+
+	q, _ = BuildQuery(OrGrouping(), AndGrouping())
+	assert.Equal("WHERE (1=0) AND (1=1)", q)
+
+	q, p = BuildQuery(Not(IsEqual("a", 1)))
+	assert.Equal("WHERE NOT (`a` = ?)", q)
+	assert.Equal([]interface{}{1}, p)
+}
+
+func TestBuildUpdate(t *testing.T) {
+	assert := assert.New(t)
+	q, p := BuildUpdate("user", []AssignmentDef{Set("name", "bob"), Increment("count", 1), SetExpr("score", "GREATEST(`score`, ?)", 10)}, IsEqual("id", 5), Descending("id"), Limit(1))
+	assert.Equal("UPDATE `user` SET `name` = ?, `count` = `count` + ?, `score` = GREATEST(`score`, ?) WHERE `id` = ? ORDER BY `id` DESC LIMIT 1", q)
+	assert.Equal([]interface{}{"bob", 1, 10, 5}, p)
+
+	q, p = BuildUpdate("user", []AssignmentDef{Set("name", nil)})
+	assert.Equal("UPDATE `user` SET `name` = ?", q)
+	assert.Equal([]interface{}{nil}, p)
+
+	q, p = BuildUpdate("user", []AssignmentDef{Set("active", false)}, InnerJoin(Table("repo"), On(TableColumn("repo", "user_id"), TableColumn("user", "id")), IsEqual("private", true)), IsEqual("login", "x"), Table("ignored"))
+	assert.Equal("UPDATE `user` INNER JOIN `repo` ON `repo`.`user_id` = `user`.`id` AND `private` = ? SET `active` = ? WHERE `login` = ?", q)
+	assert.Equal([]interface{}{true, false, "x"}, p)
+
+	q, p = BuildUpdate("user", nil, IsEqual("id", 1))
+	assert.Equal("", q)
+	assert.Nil(p)
+
+	assert.True(HasAssignment([]AssignmentDef{Set("a", 1), Increment("b", 1)}, "b"))
+	assert.False(HasAssignment([]AssignmentDef{Set("a", 1)}, "b"))
+}
+
+func TestBuildE(t *testing.T) {
+	assert := assert.New(t)
+	q, p, err := BuildQueryE(Column("id"), Table("user"), IsEqual("id", 1), Limit(1))
+	assert.Nil(err)
+	assert.Equal("SELECT `id` FROM `user` WHERE `id` = ? LIMIT 1", q)
+	assert.Equal([]interface{}{1}, p)
+
+	q, p, err = BuildUpdateE("user", []AssignmentDef{Set("a", 1)}, IsEqual("id", 1), Limit(1))
+	assert.Nil(err)
+	assert.Equal("UPDATE `user` SET `a` = ? WHERE `id` = ? LIMIT 1", q)
+	assert.Equal([]interface{}{1, 1}, p)
+
+	q, p, err = BuildDeleteE("user", IsEqual("id", 1))
+	assert.Nil(err)
+	assert.Equal("DELETE FROM `user` WHERE `id` = ?", q)
+	assert.Equal([]interface{}{1}, p)
+
+	for _, build := range []func() (string, []interface{}, error){
+		func() (string, []interface{}, error) {
+			return BuildQueryE(Column("id"), Table("user"), Limit(1), Limit(2))
+		},
+		func() (string, []interface{}, error) {
+			return BuildQueryE(Column("id"), Table("user"), OrderDef{Name: "id", Direction: "DESC; DROP TABLE user"})
+		},
+		func() (string, []interface{}, error) {
+			return BuildUpdateE("user", []AssignmentDef{Set("a", 1)}, "id = 1")
+		},
+		func() (string, []interface{}, error) {
+			return BuildDeleteE("user", IsEqual("id", 1), Limit(-1))
+		},
+		func() (string, []interface{}, error) {
+			return BuildUpdateE("user", []AssignmentDef{Set("a", 1)}, IsEqual("id", 1), Range(10, 1))
+		},
+		func() (string, []interface{}, error) {
+			return BuildDeleteE("user", IsEqual("id", 1), Range(10, 1))
+		},
+		func() (string, []interface{}, error) {
+			return BuildUpdateE("user", []AssignmentDef{Set("a", 1)}, InnerJoin(Table("repo"), Join("repo.user_id", "user.id")), Limit(1))
+		},
+		func() (string, []interface{}, error) {
+			return BuildDeleteE("user", InnerJoin(Table("repo"), Join("repo.user_id", "user.id")), Ascending("id"))
+		},
+		func() (string, []interface{}, error) {
+			return BuildDeleteE("user", GroupBy("a"))
+		},
+		func() (string, []interface{}, error) {
+			return BuildUpdateE("user", nil, IsEqual("id", 1))
+		},
+		func() (string, []interface{}, error) {
+			return BuildUpdateE("user", []AssignmentDef{Set("a", 1)}, Table("other"), IsEqual("id", 1))
+		},
+		func() (string, []interface{}, error) {
+			return BuildDeleteE("user", Column("id"), IsEqual("id", 1))
+		},
+		func() (string, []interface{}, error) {
+			return BuildDeleteE("user", IsInSubquery("id", Subquery(ColumnDef{Name: "user_id", Func: "SLEEP"}, Table("repo"))))
+		},
+		func() (string, []interface{}, error) {
+			return BuildUpdateE("user", []AssignmentDef{Set("a", 1)}, InnerJoin(Table("repo"), On(ColumnDef{Table: "repo", Name: "user_id", Func: "SLEEP"}, TableColumn("user", "id"))))
+		},
+	} {
+		q, p, err := build()
+		assert.True(errors.Is(err, ErrInvalidQuery), "%v", err)
+		assert.Equal("", q)
+		assert.Nil(p)
+	}
+}
+
+func TestBuildDelete(t *testing.T) {
+	assert := assert.New(t)
+	q, p := BuildDelete("user")
+	assert.Equal("DELETE FROM `user`", q)
+	assert.Len(p, 0)
+
+	q, p = BuildDelete("user", Column("id"), IsEqual("a", 1), OrGrouping(IsNull("b"), IsLessThan("c", 2)), Ascending("id"), Limit(10))
+	assert.Equal("DELETE FROM `user` WHERE `a` = ? AND (`b` IS NULL OR `c` < ?) ORDER BY `id` ASC LIMIT 10", q)
+	assert.Equal([]interface{}{1, 2}, p)
+
+	q, p = BuildDelete("user", LeftJoin(Table("repo"), On(TableColumn("repo", "user_id"), TableColumn("user", "id"))), IsNullExpr("`repo`.`id`"))
+	assert.Equal("DELETE `user` FROM `user` LEFT JOIN `repo` ON `repo`.`user_id` = `user`.`id` WHERE `repo`.`id` IS NULL", q)
+	assert.Len(p, 0)
+}
+
+func TestBuildQueryAnalytics(t *testing.T) {
+	assert := assert.New(t)
+	q, p := BuildQuery(
+		ColumnExprDefAlias(Expr("DATE_FORMAT(`day`, ?)", "%Y-%m"), "month"),
+		CountAlias("*", "total"),
+		Table("event"),
+		IsEqual("kind", "push"),
+		GroupByExpr("DATE_FORMAT(`day`, ?)", "%Y-%m"),
+		Having(IsGreaterThanExpr("COUNT(*)", 10), IsLessThanExpr("MAX(`size`)", 100)),
+		Descending("month"),
+		Limit(5),
+	)
+	assert.Equal("SELECT DATE_FORMAT(`day`, ?) AS `month`, COUNT(*) AS `total` FROM `event` WHERE `kind` = ? GROUP BY DATE_FORMAT(`day`, ?) HAVING (COUNT(*) > ? AND MAX(`size`) < ?) ORDER BY `month` DESC LIMIT 5", q)
+	assert.Equal([]interface{}{"%Y-%m", "push", "%Y-%m", 10, 100}, p)
+
+	q, p = BuildQuery(Column("user_id"), ColumnExprDefAlias(Expr("ROW_NUMBER() OVER (PARTITION BY `repo_id` ORDER BY `created` DESC, ?)", 1), "n"), Table("commit"), Distinct())
+	assert.Equal("SELECT DISTINCT `user_id`, ROW_NUMBER() OVER (PARTITION BY `repo_id` ORDER BY `created` DESC, ?) AS `n` FROM `commit`", q)
+	assert.Equal([]interface{}{1}, p)
+
+	q, p = BuildQuery(
+		Column("id"), Table("user"), IsEqual("active", true),
+		Union(Column("id"), Table("bot"), IsEqual("owner", "x")),
+		UnionAll(Column("id"), Table("team"), Descending("id"), Limit(2)),
+		Ascending("id"),
+		Limit(10),
+	)
+	assert.Equal("SELECT `id` FROM `user` WHERE `active` = ? UNION (SELECT `id` FROM `bot` WHERE `owner` = ?) UNION ALL (SELECT `id` FROM `team` ORDER BY `id` DESC LIMIT 2) ORDER BY `id` ASC LIMIT 10", q)
+	assert.Equal([]interface{}{true, "x"}, p)
 }
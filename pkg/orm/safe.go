@@ -0,0 +1,119 @@
+package orm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QuoteIdentifier returns the name of a table, column or alias in backticks with any backtick in it doubled so that it can't end the identifier
+func QuoteIdentifier(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+// ExprDef is a trusted SQL fragment with placeholders and the values bound to them. the values are always passed as parameters and never written into the SQL. it's the way SQL is written as is by a column, condition, grouping or assignment other than the deprecated ColumnDef.Expr and ConditionDef.Func
+type ExprDef struct {
+	SQL    string
+	Params []interface{}
+}
+
+func (e ExprDef) String() string {
+	return "(" + e.SQL + ")"
+}
+
+// AddValue appends the values of the expression to the parameters
+func (e ExprDef) AddValue(params []interface{}) []interface{} {
+	return append(params, e.Params...)
+}
+
+// Expr returns an expression which can be used as a condition. the sql must be a constant and anything from user input must be passed as a value such as Expr("DATEDIFF(`end`, `start`) > ?", days)
+func Expr(sql string, values ...interface{}) ExprDef {
+	return ExprDef{sql, values}
+}
+
+// Allowlist is the set of column names which can be used in a query built from untrusted input such as the sort and filter of an API request
+type Allowlist map[string]bool
+
+// NewAllowlist returns an allow-list of the column names
+func NewAllowlist(names ...string) Allowlist {
+	a := make(Allowlist)
+	for _, name := range names {
+		a[name] = true
+	}
+	return a
+}
+
+// Allowed returns true if the column name is in the allow-list
+func (a Allowlist) Allowed(name string) bool {
+	return a[name]
+}
+
+// Check returns a QueryError for the first component which uses a column that isn't in the allow-list. components with an expression such as those from ColumnExpr or IsEqualExpr are rejected since they can't be checked
+func (a Allowlist) Check(components ...interface{}) error {
+	for _, component := range components {
+		if err := a.check(component); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a Allowlist) check(component interface{}) error {
+	names := make([]string, 0)
+	var expr bool
+	switch c := component.(type) {
+	case ColumnDef:
+		{
+			names = append(names, c.Name)
+			expr = c.Expression.SQL != "" || c.Expr != ""
+		}
+	case ConditionDef:
+		{
+			names = append(names, c.Name)
+			_, subquery := c.Value.(SubqueryDef)
+			expr = c.Expr.SQL != "" || c.Func != "" || subquery
+		}
+	case ConditionGroupDef:
+		{
+			for _, condition := range c.Conditions {
+				if err := a.check(condition); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case NotDef:
+		{
+			return a.check(c.Condition)
+		}
+	case OrderDef:
+		{
+			names = append(names, c.Name)
+		}
+	case GroupDef:
+		{
+			names = append(names, c.Name)
+			expr = c.Expr.SQL != ""
+		}
+	case KeysetDef:
+		{
+			names = append(names, c.Names...)
+		}
+	case LimitDef, RangeDef:
+		{
+			return nil
+		}
+	default:
+		{
+			return &QueryError{fmt.Sprintf("%T can't be checked against the allow-list", component)}
+		}
+	}
+	if expr {
+		return &QueryError{"an expression can't be checked against the allow-list"}
+	}
+	for _, name := range names {
+		if a.Allowed(name) == false {
+			return &QueryError{"column " + QuoteIdentifier(name) + " is not allowed"}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package orm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("`id`", QuoteIdentifier("id"))
+	assert.Equal("`a``b`", QuoteIdentifier("a`b"))
+	q, p := BuildQuery(
+		ColumnAlias("id` FROM secret; --", "x`y"),
+		TableAlias("user`", "u"),
+		IsEqual("name` = 1 OR `1", "bob"),
+		GroupBy("g`"),
+		Descending("id` DESC; DROP TABLE user; --"),
+	)
+	assert.Equal("SELECT `id`` FROM secret; --` AS `x``y` FROM `user``` `u` WHERE `name`` = 1 OR ``1` = ? GROUP BY `g``` ORDER BY `id`` DESC; DROP TABLE user; --` DESC", q)
+	assert.Equal([]interface{}{"bob"}, p)
+	q, _ = BuildUpdate("t`", []AssignmentDef{Increment("n`", 1)})
+	assert.Equal("UPDATE `t``` SET `n``` = `n``` + ?", q)
+	q, _ = BuildDelete("t`")
+	assert.Equal("DELETE FROM `t```", q)
+}
+
+func TestExpr(t *testing.T) {
+	assert := assert.New(t)
+	e := Expr("DATEDIFF(`end`, `start`) > ?", 7)
+	q, p := BuildQuery(ColumnExprDefAlias(Expr("DATE_FORMAT(`day`, ?)", "%Y"), "year"), Table("event"), IsEqual("a", 1), Not(e), OrGrouping(e, IsNull("end")))
+	assert.Equal("SELECT DATE_FORMAT(`day`, ?) AS `year` FROM `event` WHERE `a` = ? AND NOT ((DATEDIFF(`end`, `start`) > ?)) AND ((DATEDIFF(`end`, `start`) > ?) OR `end` IS NULL)", q)
+	assert.Equal([]interface{}{"%Y", 1, 7, 7}, p)
+
+	q, p, err := Select(Column("id")).From(Table("event")).Where(e).Build()
+	assert.Nil(err)
+	assert.Equal("SELECT `id` FROM `event` WHERE (DATEDIFF(`end`, `start`) > ?)", q)
+	assert.Equal([]interface{}{7}, p)
+}
+
+func TestAllowlist(t *testing.T) {
+	assert := assert.New(t)
+	a := NewAllowlist("id", "name", "created_at")
+	assert.True(a.Allowed("id"))
+	assert.False(a.Allowed("password"))
+	assert.Nil(a.Check(Column("id"), IsEqual("name", "x"), OrGrouping(IsNull("created_at"), Not(IsIn("id", []interface{}{1}))), Descending("created_at"), GroupBy("name"), Keyset([]string{"id"}, OperatorGreaterThan, []interface{}{1}), Limit(10), Range(0, 1)))
+
+	for _, component := range []interface{}{
+		Column("password"),
+		IsEqual("password", "x"),
+		Ascending("password"),
+		AndGrouping(IsEqual("id", 1), OrGrouping(IsEqual("password", "x"))),
+		Not(IsNull("password")),
+		ColumnExpr("SLEEP(10)"),
+		IsEqualExpr("SLEEP(10)", 0),
+		GroupByExpr("password"),
+		IsInSubquery("id", Subquery(Column("id"), Table("admin"))),
+		Exists(Subquery(Column("id"), Table("admin"))),
+		Expr("1 = 1"),
+		Table("admin"),
+	} {
+		err := a.Check(IsEqual("id", 1), component)
+		assert.True(errors.Is(err, ErrInvalidQuery), "%v", component)
+	}
+	assert.Equal("orm: invalid query: column `password` is not allowed", a.Check(Ascending("password")).Error())
+}
@@ -0,0 +1,146 @@
+package orm
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Scannable is implemented by the generated models so that several of them can be scanned from one row of a join
+type Scannable interface {
+	// DBScanner returns the destinations for the columns of the model and a function which copies the scanned values into the model
+	DBScanner() ([]interface{}, func() error)
+}
+
+// ScanRow scans the current row into the models. the row must have the columns of each model (see As on the generated XColumns such as UserColumns.As("u")) in the same order as the models
+func ScanRow(rows *sql.Rows, models ...Scannable) error {
+	dest := make([]interface{}, 0)
+	apply := make([]func() error, 0, len(models))
+	for _, model := range models {
+		d, a := model.DBScanner()
+		dest = append(dest, d...)
+		apply = append(apply, a)
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return WrapError(err)
+	}
+	for _, a := range apply {
+		if err := a(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snakeCase returns the column name for a struct field such as user_id for UserID
+func snakeCase(name string) string {
+	var buf strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				buf.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
+
+// structFields returns the index of the field of the struct type for each column name. the name comes from the db tag or is the snake case of the field name. a db tag of - skips the field
+func structFields(t reflect.Type) map[string][]int {
+	fields := make(map[string][]int)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Tag.Get("db")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = snakeCase(f.Name)
+		}
+		fields[name] = f.Index
+	}
+	return fields
+}
+
+// scanStruct scans the current row into the struct value. a NULL leaves the field at its zero value and columns without a field are ignored
+func scanStruct(rows *sql.Rows, columns []string, fields map[string][]int, v reflect.Value) error {
+	dest := make([]interface{}, len(columns))
+	targets := make([]reflect.Value, len(columns))
+	for i, column := range columns {
+		index, ok := fields[column]
+		if ok == false {
+			dest[i] = new(sql.RawBytes)
+			continue
+		}
+		// scanning into a pointer to a pointer allows NULL for any field type
+		targets[i] = reflect.New(reflect.PtrTo(v.FieldByIndex(index).Type()))
+		dest[i] = targets[i].Interface()
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return WrapError(err)
+	}
+	for i, column := range columns {
+		if index, ok := fields[column]; ok {
+			if p := targets[i].Elem(); p.IsNil() == false {
+				v.FieldByIndex(index).Set(p.Elem())
+			}
+		}
+	}
+	return nil
+}
+
+// ScanStruct scans the current row into the struct which dest points to. see ScanStructs for how the columns are matched to the fields
+func ScanStruct(rows *sql.Rows, dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("orm: expected a pointer to a struct but was %T", dest)
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	return scanStruct(rows, columns, structFields(v.Elem().Type()), v.Elem())
+}
+
+// ScanStructs scans the rows into the slice of structs or struct pointers which dest points to and closes the rows. a column is matched to the field with the same db tag or else the field whose name in snake case is the column, a NULL leaves the field at its zero value and columns without a field are ignored
+func ScanStructs(rows *sql.Rows, dest interface{}) error {
+	defer rows.Close()
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("orm: expected a pointer to a slice but was %T", dest)
+	}
+	slice := v.Elem()
+	elem := slice.Type().Elem()
+	ptr := elem.Kind() == reflect.Ptr
+	if ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return fmt.Errorf("orm: expected a slice of structs but was %T", dest)
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	fields := structFields(elem)
+	for rows.Next() {
+		item := reflect.New(elem)
+		if err := scanStruct(rows, columns, fields, item.Elem()); err != nil {
+			return err
+		}
+		if ptr {
+			slice.Set(reflect.Append(slice, item))
+		} else {
+			slice.Set(reflect.Append(slice, item.Elem()))
+		}
+	}
+	return WrapError(rows.Err())
+}
@@ -0,0 +1,176 @@
+package orm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rowsDriver returns the same rows for every query so that scanning can be tested without a database
+type rowsDriver struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (d *rowsDriver) Open(name string) (driver.Conn, error) {
+	return &rowsConn{d}, nil
+}
+
+type rowsConn struct {
+	d *rowsDriver
+}
+
+func (c *rowsConn) Prepare(query string) (driver.Stmt, error) {
+	return &rowsStmt{c.d}, nil
+}
+
+func (c *rowsConn) Close() error {
+	return nil
+}
+
+func (c *rowsConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+type rowsStmt struct {
+	d *rowsDriver
+}
+
+func (s *rowsStmt) Close() error {
+	return nil
+}
+
+func (s *rowsStmt) NumInput() int {
+	return -1
+}
+
+func (s *rowsStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *rowsStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return &fakeRows{s.d, 0}, nil
+}
+
+type fakeRows struct {
+	d *rowsDriver
+	i int
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.d.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.i >= len(r.d.values) {
+		return io.EOF
+	}
+	copy(dest, r.d.values[r.i])
+	r.i++
+	return nil
+}
+
+var testRowsDriver = &rowsDriver{}
+
+func init() {
+	sql.Register("ormrows", testRowsDriver)
+}
+
+type scanReport struct {
+	UserID   int64
+	Name     string
+	Repo     *string
+	Count    int    `db:"total"`
+	Ignored  string `db:"-"`
+	internal string
+}
+
+func TestScanStructs(t *testing.T) {
+	assert := assert.New(t)
+	testRowsDriver.columns = []string{"user_id", "name", "repo", "total", "extra"}
+	testRowsDriver.values = [][]driver.Value{
+		{int64(1), []byte("a"), []byte("r"), int64(5), []byte("x")},
+		{int64(2), nil, nil, int64(0), nil},
+	}
+	db, _ := sql.Open("ormrows", "")
+	defer db.Close()
+
+	rows, err := db.Query("SELECT")
+	assert.Nil(err)
+	var reports []scanReport
+	assert.Nil(ScanStructs(rows, &reports))
+	assert.Len(reports, 2)
+	assert.Equal(int64(1), reports[0].UserID)
+	assert.Equal("a", reports[0].Name)
+	assert.Equal("r", *reports[0].Repo)
+	assert.Equal(5, reports[0].Count)
+	assert.Equal(int64(2), reports[1].UserID)
+	assert.Equal("", reports[1].Name)
+	assert.Nil(reports[1].Repo)
+
+	rows, err = db.Query("SELECT")
+	assert.Nil(err)
+	var pointers []*scanReport
+	assert.Nil(ScanStructs(rows, &pointers))
+	assert.Len(pointers, 2)
+
+	rows, err = db.Query("SELECT")
+	assert.Nil(err)
+	defer rows.Close()
+	assert.True(rows.Next())
+	var report scanReport
+	assert.Nil(ScanStruct(rows, &report))
+	assert.Equal(int64(1), report.UserID)
+	assert.NotNil(ScanStruct(rows, report))
+}
+
+type scanModel struct {
+	id   sql.NullInt64
+	name sql.NullString
+	ID   int64
+	Name string
+}
+
+func (m *scanModel) DBScanner() ([]interface{}, func() error) {
+	return []interface{}{&m.id, &m.name}, func() error {
+		m.ID = m.id.Int64
+		m.Name = m.name.String
+		return nil
+	}
+}
+
+func TestScanRow(t *testing.T) {
+	assert := assert.New(t)
+	testRowsDriver.columns = []string{"id", "name", "id", "name"}
+	testRowsDriver.values = [][]driver.Value{
+		{int64(1), []byte("a"), int64(2), []byte("b")},
+	}
+	db, _ := sql.Open("ormrows", "")
+	defer db.Close()
+	rows, err := db.Query("SELECT")
+	assert.Nil(err)
+	defer rows.Close()
+	assert.True(rows.Next())
+	a, b := &scanModel{}, &scanModel{}
+	assert.Nil(ScanRow(rows, a, b))
+	assert.Equal(int64(1), a.ID)
+	assert.Equal("a", a.Name)
+	assert.Equal(int64(2), b.ID)
+	assert.Equal("b", b.Name)
+}
+
+func TestSnakeCase(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("user_id", snakeCase("UserID"))
+	assert.Equal("name", snakeCase("Name"))
+	assert.Equal("created_at", snakeCase("CreatedAt"))
+	assert.Equal("http_status", snakeCase("HTTPStatus"))
+}
@@ -0,0 +1,70 @@
+package orm
+
+// SoftDeleteDef selects which records of a table with a soft delete column are returned by a query
+type SoftDeleteDef struct {
+	// Active includes the records which haven't been deleted
+	Active bool
+	// Deleted includes the records which have been soft deleted
+	Deleted bool
+}
+
+// WithDeleted returns a scope which includes the soft deleted records
+func WithDeleted() SoftDeleteDef {
+	return SoftDeleteDef{Active: true, Deleted: true}
+}
+
+// OnlyDeleted returns a scope which only includes the soft deleted records
+func OnlyDeleted() SoftDeleteDef {
+	return SoftDeleteDef{Deleted: true}
+}
+
+// softDeleteScope returns the last scope passed which defaults to the records which haven't been deleted
+func softDeleteScope(scope []SoftDeleteDef) SoftDeleteDef {
+	if len(scope) == 0 {
+		return SoftDeleteDef{Active: true}
+	}
+	return scope[len(scope)-1]
+}
+
+// SoftDeleteCondition returns the condition on the soft delete column for the scope and false if no condition is needed
+func SoftDeleteCondition(column string, scope ...SoftDeleteDef) (ConditionDef, bool) {
+	s := softDeleteScope(scope)
+	switch {
+	case s.Active && s.Deleted:
+		{
+			return ConditionDef{}, false
+		}
+	case s.Deleted:
+		{
+			return IsNotNull(column), true
+		}
+	}
+	return IsNull(column), true
+}
+
+// SoftDeleteClause returns the AND clause on the soft delete column for the scope which can be appended to a WHERE
+func SoftDeleteClause(column string, scope ...SoftDeleteDef) string {
+	if c, ok := SoftDeleteCondition(column, scope...); ok {
+		return " AND " + c.String()
+	}
+	return ""
+}
+
+// SoftDelete returns the query params with the soft delete condition for the column first. any WithDeleted or OnlyDeleted scope in the params is used and removed
+func SoftDelete(column string, params []interface{}) []interface{} {
+	scope := make([]SoftDeleteDef, 0)
+	result := make([]interface{}, 1, len(params)+1)
+	for _, param := range params {
+		if s, ok := param.(SoftDeleteDef); ok {
+			scope = append(scope, s)
+			continue
+		}
+		result = append(result, param)
+	}
+	c, ok := SoftDeleteCondition(column, scope...)
+	if ok == false {
+		return result[1:]
+	}
+	result[0] = c
+	return result
+}
@@ -0,0 +1,30 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSoftDeleteClause(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(" AND `deleted_at` IS NULL", SoftDeleteClause("deleted_at"))
+	assert.Equal("", SoftDeleteClause("deleted_at", WithDeleted()))
+	assert.Equal(" AND `deleted_at` IS NOT NULL", SoftDeleteClause("deleted_at", OnlyDeleted()))
+}
+
+func TestSoftDelete(t *testing.T) {
+	assert := assert.New(t)
+	params := append([]interface{}{Column("id"), Table("user")}, SoftDelete("deleted_at", []interface{}{IsEqual("name", "foo"), Limit(1)})...)
+	q, p := BuildQuery(params...)
+	assert.Equal("SELECT `id` FROM `user` WHERE `deleted_at` IS NULL AND `name` = ? LIMIT 1", q)
+	assert.Len(p, 1)
+
+	params = append([]interface{}{Column("id"), Table("user")}, SoftDelete("deleted_at", []interface{}{WithDeleted(), IsEqual("name", "foo")})...)
+	q, _ = BuildQuery(params...)
+	assert.Equal("SELECT `id` FROM `user` WHERE `name` = ?", q)
+
+	params = append([]interface{}{Column("id"), Table("user")}, SoftDelete("deleted_at", []interface{}{OnlyDeleted()})...)
+	q, _ = BuildQuery(params...)
+	assert.Equal("SELECT `id` FROM `user` WHERE `deleted_at` IS NOT NULL", q)
+}
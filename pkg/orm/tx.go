@@ -0,0 +1,107 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// TxOptions configures how WithTx begins and retries a transaction
+type TxOptions struct {
+	// Isolation is the isolation level of the transaction
+	Isolation sql.IsolationLevel
+	// ReadOnly begins a read only transaction
+	ReadOnly bool
+	// MaxRetries is the number of times the transaction is retried after a deadlock or lock wait timeout. it defaults to 3 and a negative value turns off retries
+	MaxRetries int
+	// Backoff is the delay before the first retry which doubles for each retry. it defaults to 10ms
+	Backoff time.Duration
+}
+
+// TxBeginner is implemented by *sql.DB and *sql.Conn which can begin a transaction
+type TxBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// IsRetryable returns true if the error is a MySQL deadlock or lock wait timeout which means the transaction can be retried
+func IsRetryable(err error) bool {
+	return errors.Is(WrapError(err), ErrTransient)
+}
+
+var savepoints uint64
+
+// WithTx calls fn inside a transaction which is committed if fn returns nil and rolled back if it returns an error or panics. a deadlock or lock wait timeout retries the whole transaction with a backoff so fn should not have side effects outside the database. when db is a *sql.Tx the call is nested and fn runs inside a SAVEPOINT which is rolled back on error, leaving any retry to the outermost call
+func WithTx(ctx context.Context, db Executor, opts *TxOptions, fn func(tx *sql.Tx) error) error {
+	if opts == nil {
+		opts = &TxOptions{}
+	}
+	switch d := db.(type) {
+	case *sql.Tx:
+		{
+			return withSavepoint(ctx, d, fn)
+		}
+	case TxBeginner:
+		{
+			retries := opts.MaxRetries
+			if retries == 0 {
+				retries = 3
+			}
+			backoff := opts.Backoff
+			if backoff <= 0 {
+				backoff = 10 * time.Millisecond
+			}
+			for attempt := 0; ; attempt++ {
+				err := withTx(ctx, d, opts, fn)
+				if err == nil || attempt >= retries || IsRetryable(err) == false {
+					return err
+				}
+				select {
+				case <-ctx.Done():
+					return err
+				case <-time.After(backoff << uint(attempt)):
+				}
+			}
+		}
+	}
+	return fmt.Errorf("orm: %T can't begin a transaction", db)
+}
+
+func withTx(ctx context.Context, db TxBeginner, opts *TxOptions, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return WrapError(tx.Commit())
+}
+
+func withSavepoint(ctx context.Context, tx *sql.Tx, fn func(tx *sql.Tx) error) error {
+	name := fmt.Sprintf("orm_savepoint_%d", atomic.AddUint64(&savepoints, 1))
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+			panic(p)
+		}
+	}()
+	if err := fn(tx); err != nil {
+		tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+		return err
+	}
+	_, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return err
+}
@@ -0,0 +1,151 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+// txDriver records the statements of a connection so that the transaction handling can be tested without a database
+type txDriver struct {
+	log []string
+}
+
+func (d *txDriver) Open(name string) (driver.Conn, error) {
+	return &txConn{d}, nil
+}
+
+type txConn struct {
+	d *txDriver
+}
+
+func (c *txConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *txConn) Close() error {
+	return nil
+}
+
+func (c *txConn) Begin() (driver.Tx, error) {
+	c.d.log = append(c.d.log, "BEGIN")
+	return c, nil
+}
+
+func (c *txConn) Commit() error {
+	c.d.log = append(c.d.log, "COMMIT")
+	return nil
+}
+
+func (c *txConn) Rollback() error {
+	c.d.log = append(c.d.log, "ROLLBACK")
+	return nil
+}
+
+func (c *txConn) Exec(query string, args []driver.Value) (driver.Result, error) {
+	c.d.log = append(c.d.log, query)
+	return driver.RowsAffected(0), nil
+}
+
+var testTxDriver = &txDriver{}
+
+func init() {
+	sql.Register("ormtx", testTxDriver)
+}
+
+func openTxDB(t *testing.T) *sql.DB {
+	testTxDriver.log = nil
+	db, err := sql.Open("ormtx", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestWithTxCommitRollback(t *testing.T) {
+	assert := assert.New(t)
+	db := openTxDB(t)
+	defer db.Close()
+	err := WithTx(context.Background(), db, nil, func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE a")
+		return err
+	})
+	assert.Nil(err)
+	assert.Equal([]string{"BEGIN", "UPDATE a", "COMMIT"}, testTxDriver.log)
+
+	testTxDriver.log = nil
+	boom := errors.New("boom")
+	err = WithTx(context.Background(), db, nil, func(tx *sql.Tx) error {
+		return boom
+	})
+	assert.Equal(boom, err)
+	assert.Equal([]string{"BEGIN", "ROLLBACK"}, testTxDriver.log)
+}
+
+func TestWithTxPanic(t *testing.T) {
+	assert := assert.New(t)
+	db := openTxDB(t)
+	defer db.Close()
+	assert.Panics(func() {
+		WithTx(context.Background(), db, nil, func(tx *sql.Tx) error {
+			panic("boom")
+		})
+	})
+	assert.Equal([]string{"BEGIN", "ROLLBACK"}, testTxDriver.log)
+}
+
+func TestWithTxRetry(t *testing.T) {
+	assert := assert.New(t)
+	db := openTxDB(t)
+	defer db.Close()
+	var calls int
+	err := WithTx(context.Background(), db, &TxOptions{Backoff: time.Millisecond}, func(tx *sql.Tx) error {
+		calls++
+		if calls < 3 {
+			return &mysql.MySQLError{Number: ErrorNumberDeadlock}
+		}
+		return nil
+	})
+	assert.Nil(err)
+	assert.Equal(3, calls)
+	assert.Equal([]string{"BEGIN", "ROLLBACK", "BEGIN", "ROLLBACK", "BEGIN", "COMMIT"}, testTxDriver.log)
+
+	calls = 0
+	err = WithTx(context.Background(), db, &TxOptions{MaxRetries: -1}, func(tx *sql.Tx) error {
+		calls++
+		return &mysql.MySQLError{Number: ErrorNumberLockWaitTimeout}
+	})
+	assert.True(IsRetryable(err))
+	assert.Equal(1, calls)
+	assert.False(IsRetryable(errors.New("boom")))
+}
+
+func TestWithTxSavepoint(t *testing.T) {
+	assert := assert.New(t)
+	db := openTxDB(t)
+	defer db.Close()
+	boom := errors.New("boom")
+	err := WithTx(context.Background(), db, nil, func(tx *sql.Tx) error {
+		assert.Nil(WithTx(context.Background(), tx, nil, func(tx *sql.Tx) error {
+			return nil
+		}))
+		assert.Equal(boom, WithTx(context.Background(), tx, nil, func(tx *sql.Tx) error {
+			return boom
+		}))
+		return nil
+	})
+	assert.Nil(err)
+	assert.Len(testTxDriver.log, 6)
+	assert.Equal("BEGIN", testTxDriver.log[0])
+	assert.Regexp("^SAVEPOINT orm_savepoint_\\d+$", testTxDriver.log[1])
+	assert.Regexp("^RELEASE SAVEPOINT orm_savepoint_\\d+$", testTxDriver.log[2])
+	assert.Regexp("^SAVEPOINT orm_savepoint_\\d+$", testTxDriver.log[3])
+	assert.Regexp("^ROLLBACK TO SAVEPOINT orm_savepoint_\\d+$", testTxDriver.log[4])
+	assert.Equal("COMMIT", testTxDriver.log[5])
+}
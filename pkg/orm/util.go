@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"database/sql/driver"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -422,10 +423,9 @@ func ToGeometry(point string) *Geometry {
 	return &Geometry{}
 }
 
-// ToTimestampNow returns the proto Timestamp from curent time
+// ToTimestampNow returns the proto Timestamp from curent time of the clock (see SetClock)
 func ToTimestampNow() *tspb.Timestamp {
-	ts, _ := ptypes.TimestampProto(time.Now())
-	return ts
+	return ToTimestampTime(Now())
 }
 
 // ToTimestamp returns a proto Timestamp from a mysql.NullTime
@@ -444,9 +444,11 @@ func ISODate() string {
 	return time.Now().UTC().Format(time.RFC3339)
 }
 
-// UUID returns a unique ID
+// UUID returns a unique 64 character hex ID from 256 random bits. use UUIDv4, UUIDv7 or ULID for standard identifiers
 func UUID() string {
-	return HashStrings(ISODate(), fmt.Sprintf("%d", RandUID()))
+	b := make([]byte, 32)
+	randomBytes(b)
+	return hex.EncodeToString(b)
 }
 
 // RandUID returns a random int32